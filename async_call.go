@@ -1,10 +1,9 @@
 package hub
 
 import (
-	"errors"
-	"runtime"
-
-	"github.com/rs/zerolog/log"
+	"context"
+	"runtime/debug"
+	"time"
 )
 
 type asyncReturn Return
@@ -15,18 +14,26 @@ type Return struct {
 	Error    error
 	callback func(Return)
 	out      chan interface{}
+	ctx      context.Context // SlowCallContext 的 ctx，回调以其为父 span
 }
 
 type asyncCall struct {
-	out  chan interface{} // 接收返回值
-	exec func()           // 执行异步方法
+	ctx    context.Context
+	arg    interface{}
+	out    chan interface{} // 接收返回值
+	exec   func()           // 执行异步方法
+	queued time.Time        // 进入收件箱的时间
 }
 
 // 构建异步调用
+// 	trace 非 nil 时，记录 fn 执行的 span
 func newAsyncCall(
-	fn func(arg interface{}) Return,
+	ctx context.Context,
+	fn func(ctx context.Context, arg interface{}) Return,
 	arg interface{},
 	callback func(arg Return),
+	onPanic func(arg, r interface{}, stack []byte),
+	trace func(ctx context.Context) (context.Context, Span),
 ) asyncCall {
 
 	var out chan interface{}
@@ -37,59 +44,52 @@ func newAsyncCall(
 	}
 
 	ac := asyncCall{
-		out: out,
+		ctx:    ctx,
+		arg:    arg,
+		out:    out,
+		queued: time.Now(),
 		exec: func() {
-			go asyncExec(out, fn, arg, callback)
+			go asyncExec(ctx, out, fn, arg, callback, onPanic, trace)
 		},
 	}
 
 	return ac
 }
 
-// 包裹回调，接收放回值，并释放out通道
-func recvAsyncReturn(
-	out chan interface{},
-	callback func(arg Return),
-) func() {
-
-	return func() {
-		v, ok := <-out
-		if !ok {
-			if callback != nil {
-				callback(Return{Error: errors.New("return chan is closed before")})
-			}
-			return
-		}
-		if callback != nil {
-			callback(Return(v.(asyncReturn)))
-		}
-		close(out)
-	}
-}
-
-// 异步执行，panic保护释放掉out
+// 异步执行，panic 时报告，并以 *PanicError 作为返回值送回
 func asyncExec(
+	ctx context.Context,
 	out chan interface{},
-	fn func(arg interface{}) Return,
+	fn func(ctx context.Context, arg interface{}) Return,
 	arg interface{},
 	recv func(Return),
+	onPanic func(arg, r interface{}, stack []byte),
+	trace func(ctx context.Context) (context.Context, Span),
 ) {
-	if r := recover(); r != nil {
-		if stackBufferSize > 0 {
-			buf := make([]byte, stackBufferSize)
-			l := runtime.Stack(buf, false)
-			log.Printf("%v: %s", r, buf[:l])
-		} else {
-			log.Printf("%v", r)
+	fnCtx := ctx
+	var span Span
+	if trace != nil {
+		fnCtx, span = trace(ctx)
+	}
+
+	var ar Return
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			onPanic(arg, r, stack)
+			ar = Return{Error: &PanicError{Value: r, Stack: stack}}
+		}
+		if span != nil {
+			span.End(time.Now(), ar.Error)
 		}
 
-		close(out)
-	}
+		ar.out = out
+		ar.callback = recv
+		ar.ctx = ctx
+		if out != nil {
+			out <- asyncReturn(ar)
+		}
+	}()
 
-	ar := fn(arg)
-	ar.out = out
-	ar.callback = recv
-	if out != nil {
-		out <- asyncReturn(ar)
-	}
+	ar = fn(fnCtx, arg)
 }
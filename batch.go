@@ -0,0 +1,198 @@
+package hub
+
+import (
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// 是否可以批量处理该通道的数据，内部通道和 AttachTyped 添加的通道不批量处理
+func (info *producerInfo) batchable() bool {
+	return info.handler == nil && info.level != priorityControl
+}
+
+// 从通道 ch 继续接收数据，直到凑满 batchSize 条，或等待 batchWait 后仍不足
+// 	通道关闭时 closed 为 true，hub 停止时不再等待
+func (h *Hub) collect(ch reflect.Value, batch []interface{}) (_ []interface{}, closed bool) {
+	batch, closed = h.tryCollect(ch, batch, h.batchSize)
+	if closed || len(batch) >= h.batchSize || h.batchWait <= 0 {
+		return batch, closed
+	}
+
+	timer := time.NewTimer(h.batchWait)
+	defer timer.Stop()
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: ch},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(timer.C)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(h.quit)},
+	}
+	for len(batch) < h.batchSize {
+		chosen, recv, ok := reflect.Select(cases)
+		if chosen != 0 {
+			break
+		}
+		if !ok {
+			return batch, true
+		}
+		batch = append(batch, recv.Interface())
+	}
+	return batch, false
+}
+
+// 从通道 ch 接收已就绪的数据，直到 batch 有 n 条或通道为空，不等待
+func (h *Hub) tryCollect(ch reflect.Value, batch []interface{}, n int) (_ []interface{}, closed bool) {
+	for len(batch) < n {
+		recv, ok := ch.TryRecv()
+		if !ok {
+			return batch, recv.IsValid()
+		}
+		batch = append(batch, recv.Interface())
+	}
+	return batch, false
+}
+
+// drain 时批量处理通道 ch 中最多 n 条已排队的数据，batch 为之前已取出的数据
+// 	info 返回通道的信息，通道已被移除时返回 nil
+// 	通道关闭时返回 true，由调用方移除
+func (h *Hub) drainBatch(ch reflect.Value, n int, batch []interface{}, info func() *producerInfo) bool {
+	for {
+		pi := info()
+		if pi == nil {
+			h.batch = release(batch)
+			return false
+		}
+
+		want := len(batch) + n
+		if want > h.batchSize {
+			want = h.batchSize
+		}
+		before := len(batch)
+		var closed bool
+		batch, closed = h.tryCollect(ch, batch, want)
+		n -= len(batch) - before
+		full := len(batch) == want
+
+		if len(batch) > 0 {
+			h.dispatchBatch(pi, ch.Interface(), batch)
+		}
+		h.batch = release(batch)
+		if closed || !full || n <= 0 {
+			return closed
+		}
+		batch = h.batch
+	}
+}
+
+// select 引擎批量处理第 i 个通道的数据，first 为已收到的数据
+func (h *Hub) batchSelect(i int, first interface{}) {
+	ch := h.ps.cases[i].Chan
+	batch, closed := h.collect(ch, append(h.batch[:0], first))
+	h.dispatchBatch(&h.ps.infos[i], ch.Interface(), batch)
+	h.batch = release(batch)
+
+	if closed {
+		// 通道可能已在处理中被移除
+		if i = h.indexOf(ch); i != -1 {
+			info := h.ps.infos[i]
+			h.ps.remove(i)
+			h.closed(&info, ch.Interface())
+		}
+	}
+}
+
+// funnel 引擎批量处理 e 的数据，first 为已收到的数据
+//
+// 暂停转发协程后直接从通道接收，保持数据顺序
+func (h *Hub) batchFunnel(e *funnelEntry, first interface{}) {
+	batch := append(h.batch[:0], first)
+	if data, ok := h.fn.halt(e); ok {
+		batch = append(batch, data)
+	}
+
+	batch, closed := h.collect(e.ch, batch)
+	h.dispatchBatch(&e.producerInfo, e.producer, batch)
+	h.batch = release(batch)
+
+	if e.removed {
+		return
+	}
+	if closed {
+		h.fn.remove(e)
+		h.closed(&e.producerInfo, e.producer)
+		return
+	}
+	h.fn.start(e)
+}
+
+// 清空 batch 以便复用，不保留对数据的引用
+func release(batch []interface{}) []interface{} {
+	for i := range batch {
+		batch[i] = nil
+	}
+	return batch[:0]
+}
+
+// 批量处理通道 source 收到的数据，IBatchProcessor 一次处理整批，其他处理器逐条处理
+//
+// panic 时本批剩余的数据被丢弃
+func (h *Hub) dispatchBatch(info *producerInfo, source interface{}, batch []interface{}) {
+	// 各数据的接收序号，经 OnBatch 处理后无法对应，Envelope.Seq 为0
+	seq := info.received + 1
+	info.received += uint64(len(batch))
+	atomic.AddUint64(&h.metrics.received, uint64(len(batch)))
+	aligned := true
+
+	var now time.Time
+	cursor := h.processors.Cursor()
+	for len(batch) > 0 && cursor.Next() {
+		p := cursor.Value()
+		name := p.Name()
+		h.source = source
+		h.mark(name, batch)
+		start := time.Now()
+		if bp, ok := p.(IBatchProcessor); ok {
+			batch = bp.OnBatch(batch)
+			h.observeProcessor(p, name, start)
+			aligned = false
+			continue
+		}
+
+		ep, isEnvelope := p.(IEnvelopeProcessor)
+		if isEnvelope && now.IsZero() {
+			now = h.now()
+		}
+
+		next := make([]interface{}, 0, len(batch))
+		for k, data := range batch {
+			if data == nil {
+				continue
+			}
+
+			h.mark(name, data)
+			if isEnvelope {
+				env := &Envelope{Producer: source, Meta: info.meta, Time: now, Data: data}
+				if aligned {
+					env.Seq = seq + uint64(k)
+				}
+				data = ep.OnEnvelope(env)
+			} else {
+				data = p.OnData(data)
+			}
+			if data != nil {
+				next = append(next, data)
+			}
+		}
+		h.observeProcessor(p, name, start)
+
+		// 有数据被处理后，序号无法对应
+		aligned = aligned && len(next) == len(batch)
+		batch = next
+	}
+
+	for _, data := range batch {
+		if data != nil && h.onDead != nil {
+			h.onDead(DeadUnhandled, "", source, data)
+		}
+	}
+}
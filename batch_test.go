@@ -0,0 +1,115 @@
+package hub
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 记录每批数据，偶数处理结束，其他数据交给后续处理器
+type tBatchProcessor struct {
+	mu      sync.Mutex
+	batches [][]interface{}
+}
+
+func (*tBatchProcessor) Name() string {
+	return "tBatchProcessor"
+}
+
+func (p *tBatchProcessor) OnData(data interface{}) interface{} {
+	if left := p.OnBatch([]interface{}{data}); len(left) > 0 {
+		return left[0]
+	}
+	return nil
+}
+
+func (p *tBatchProcessor) OnBatch(batch []interface{}) []interface{} {
+	p.mu.Lock()
+	p.batches = append(p.batches, append([]interface{}(nil), batch...))
+	p.mu.Unlock()
+
+	var left []interface{}
+	for _, v := range batch {
+		if n, ok := v.(int); !ok || n%2 == 1 {
+			left = append(left, v)
+		}
+	}
+	return left
+}
+
+func Test_Batch(t *testing.T) {
+	for _, e := range engines {
+		t.Run(e.name, func(t *testing.T) {
+			t.Run("凑满一批", func(t *testing.T) {
+				bp, op := &tBatchProcessor{}, &tOrderProcessor{}
+				g := NewGroup(GroupEngine(e.engine), GroupBatch(100, 0), GroupHandles(bp, op))
+				ch := make(chan int, 250)
+				for i := 0; i < 250; i++ {
+					ch <- i
+				}
+				g.Attach(ch)
+				if err := g.Shutdown(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+
+				n := 0
+				for _, b := range bp.batches {
+					if len(b) > 100 {
+						t.Fatalf("batch size %d", len(b))
+					}
+					for _, v := range b {
+						if v != n {
+							t.Fatalf("got %v want %d", v, n)
+						}
+						n++
+					}
+				}
+				if n != 250 || len(bp.batches) > 5 {
+					t.Fatalf("%d items in %d batches", n, len(bp.batches))
+				}
+
+				// 未实现 IBatchProcessor 的处理器逐条收到 OnBatch 返回的数据
+				if len(op.order) != 125 || op.order[0] != 1 || op.order[124] != 249 {
+					t.Fatalf("order %v", op.order)
+				}
+			})
+
+			t.Run("等待更多数据", func(t *testing.T) {
+				bp := &tBatchProcessor{}
+				g := NewGroup(GroupEngine(e.engine), GroupBatch(2, time.Second), GroupHandles(bp))
+				ch := make(chan int)
+				g.Attach(ch)
+
+				ch <- 2
+				time.Sleep(20 * time.Millisecond)
+				ch <- 4
+				if err := g.Shutdown(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+				if len(bp.batches) != 1 || len(bp.batches[0]) != 2 {
+					t.Fatalf("batches %v", bp.batches)
+				}
+			})
+
+			t.Run("通道关闭", func(t *testing.T) {
+				bp, op := &tBatchProcessor{}, &tOrderProcessor{}
+				g := NewGroup(GroupEngine(e.engine), GroupBatch(10, time.Second), GroupHandles(bp, op))
+				ch := make(chan int, 3)
+				ch <- 1
+				ch <- 3
+				close(ch)
+				g.Attach(ch)
+				if err := g.Shutdown(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+
+				// 关闭前的数据批量处理，不等待 batchWait
+				if len(op.order) != 3 || op.order[0] != 1 || op.order[1] != 3 ||
+					op.order[2] != (ProducerClosed{Producer: ch}) {
+					t.Fatalf("order %v", op.order)
+				}
+			})
+		})
+	}
+}
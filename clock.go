@@ -0,0 +1,38 @@
+package hub
+
+import "time"
+
+// 时钟，定时器、计划任务通过它获取时间
+//
+// 测试中可用 hubtest.FakeClock 替换，手动拨动时间
+type Clock interface {
+	Now() time.Time
+	// 创建 ticker，返回的 ticker 在 Stop、Reset 前后使用同一个通道
+	NewTicker(d time.Duration) Ticker
+}
+
+// 同 time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// 系统时钟
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
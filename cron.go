@@ -0,0 +1,449 @@
+package hub
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 错过执行时间的处理策略
+type MissedPolicy int
+
+const (
+	// 跳过错过的执行，只执行一次，然后从当前时间计算下次执行时间
+	MissedSkip MissedPolicy = iota
+	// 补齐每一次错过的执行，最多补齐 maxCatchUpRuns 次
+	MissedCatchUp
+)
+
+// 单次补齐的最大执行次数，避免长时间停顿后集中执行过多
+const maxCatchUpRuns = 1024
+
+type scheduleConfig struct {
+	Location *time.Location
+	Missed   MissedPolicy
+}
+
+type ScheduleOption func(sc *scheduleConfig)
+
+// 计算执行时间使用的时区，默认 time.Local
+//
+// spec 中以 CRON_TZ= 或 TZ= 指定的时区优先
+func ScheduleLocation(loc *time.Location) func(sc *scheduleConfig) {
+	return func(sc *scheduleConfig) {
+		sc.Location = loc
+	}
+}
+
+// 错过执行时间的处理策略，默认 MissedSkip
+func ScheduleMissed(policy MissedPolicy) func(sc *scheduleConfig) {
+	return func(sc *scheduleConfig) {
+		sc.Missed = policy
+	}
+}
+
+// 按 cron 表达式定时执行的任务
+type CronJob struct {
+	g        *Group
+	schedule cronSchedule
+	missed   MissedPolicy
+	fn       func()
+
+	mu      sync.Mutex
+	timer   *Timer
+	next    time.Time
+	stopped bool
+}
+
+// 按 cron 表达式定时执行，fn 在 group 协程中执行
+//
+// 	spec 支持5段（分 时 日 月 周）、6段（秒 分 时 日 月 周）表达式，
+// 	以及 @yearly、@monthly、@weekly、@daily、@hourly、@every <duration> 等描述符，
+// 	可用 CRON_TZ=Asia/Shanghai 前缀指定时区
+func (g *Group) Schedule(spec string, fn func(), options ...ScheduleOption) (*CronJob, error) {
+	config := scheduleConfig{
+		Location: time.Local,
+	}
+	for _, option := range options {
+		option(&config)
+	}
+
+	schedule, err := parseCron(spec, config.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	now := g.clock.Now()
+	next := schedule.next(now)
+	if next.IsZero() {
+		return nil, fmt.Errorf("cron %q: no activation time", spec)
+	}
+
+	j := &CronJob{
+		g:        g,
+		schedule: schedule,
+		missed:   config.Missed,
+		fn:       fn,
+		next:     next,
+	}
+
+	j.mu.Lock()
+	j.timer = g.AfterFunc(next.Sub(now), j.run)
+	j.mu.Unlock()
+	return j, nil
+}
+
+// 下次执行时间，停止后返回零值
+func (j *CronJob) Next() time.Time {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.stopped {
+		return time.Time{}
+	}
+	return j.next
+}
+
+// 停止任务，返回 false 表示任务已经停止
+func (j *CronJob) Stop() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.stopped {
+		return false
+	}
+
+	j.stopped = true
+	j.timer.Stop()
+	return true
+}
+
+// 在 group 协程中执行，先安排下次执行，再调用 fn
+func (j *CronJob) run() {
+	j.mu.Lock()
+	if j.stopped {
+		j.mu.Unlock()
+		return
+	}
+
+	now := j.g.clock.Now()
+	runs := 1
+	next := j.schedule.next(j.next)
+	if j.missed == MissedCatchUp {
+		for !next.IsZero() && !next.After(now) && runs < maxCatchUpRuns {
+			runs++
+			next = j.schedule.next(next)
+		}
+	}
+	if !next.IsZero() && !next.After(now) {
+		next = j.schedule.next(now)
+	}
+
+	j.next = next
+	if next.IsZero() {
+		j.stopped = true
+	} else {
+		j.timer.Reset(next.Sub(now))
+	}
+	j.mu.Unlock()
+
+	for i := 0; i < runs; i++ {
+		j.fn()
+	}
+}
+
+// 计算下次执行时间，返回零值表示不再执行
+type cronSchedule interface {
+	next(t time.Time) time.Time
+}
+
+// @every 固定间隔
+type everySchedule struct {
+	every time.Duration
+}
+
+func (s everySchedule) next(t time.Time) time.Time {
+	return t.Add(s.every)
+}
+
+// cron 表达式，各字段以位图表示允许的值
+type cronSpec struct {
+	second, minute, hour, dom, month, dow uint64
+	loc                                   *time.Location
+}
+
+// cron 字段的取值范围
+type cronField struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	secondField = cronField{0, 59, nil}
+	minuteField = cronField{0, 59, nil}
+	hourField   = cronField{0, 23, nil}
+	domField    = cronField{1, 31, nil}
+	monthField  = cronField{1, 12, map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = cronField{0, 7, map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// 字段为 * 或 ? 时的标记位，用于日、周的匹配规则
+const starBit = 1 << 63
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// 解析 cron 表达式
+func parseCron(spec string, loc *time.Location) (cronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		i := strings.IndexByte(spec, ' ')
+		if i == -1 {
+			return nil, fmt.Errorf("cron %q: missing fields", spec)
+		}
+
+		var err error
+		name := spec[strings.IndexByte(spec, '=')+1 : i]
+		if loc, err = time.LoadLocation(name); err != nil {
+			return nil, fmt.Errorf("cron %q: %w", spec, err)
+		}
+		spec = strings.TrimSpace(spec[i:])
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		every, err := time.ParseDuration(strings.TrimSpace(spec[len("@every "):]))
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: %w", spec, err)
+		}
+		if every <= 0 {
+			return nil, fmt.Errorf("cron %q: interval must be positive", spec)
+		}
+		return everySchedule{every}, nil
+	}
+
+	if strings.HasPrefix(spec, "@") {
+		expr, ok := cronDescriptors[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("cron %q: unknown descriptor", spec)
+		}
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron %q: expected 5 or 6 fields, got %d", spec, len(fields))
+	}
+
+	s := &cronSpec{loc: loc}
+	targets := []struct {
+		bits  *uint64
+		field cronField
+	}{
+		{&s.second, secondField},
+		{&s.minute, minuteField},
+		{&s.hour, hourField},
+		{&s.dom, domField},
+		{&s.month, monthField},
+		{&s.dow, dowField},
+	}
+	for i, target := range targets {
+		bits, err := target.field.parse(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: %w", spec, err)
+		}
+		*target.bits = bits
+	}
+	if s.dow&(1<<7) > 0 {
+		s.dow |= 1 // 周日可写作7
+	}
+
+	return s, nil
+}
+
+// 解析单个字段，如 *、*/5、1-10/2、mon,wed,fri
+func (f cronField) parse(field string) (uint64, error) {
+	var bits uint64
+	for _, expr := range strings.Split(field, ",") {
+		b, err := f.parseRange(expr)
+		if err != nil {
+			return 0, err
+		}
+		bits |= b
+	}
+	return bits, nil
+}
+
+func (f cronField) parseRange(expr string) (uint64, error) {
+	rangeExpr, stepExpr, hasStep := strings.Cut(expr, "/")
+
+	var start, end uint
+	var star bool
+	switch rangeExpr {
+	case "*", "?":
+		start, end, star = f.min, f.max, true
+	default:
+		lo, hi, isRange := strings.Cut(rangeExpr, "-")
+		var err error
+		if start, err = f.value(lo); err != nil {
+			return 0, err
+		}
+		end = start
+		if isRange {
+			if end, err = f.value(hi); err != nil {
+				return 0, err
+			}
+		} else if hasStep {
+			end = f.max
+		}
+	}
+
+	step := uint(1)
+	if hasStep {
+		n, err := strconv.ParseUint(stepExpr, 10, 8)
+		if err != nil || n == 0 {
+			return 0, fmt.Errorf("invalid step %q", expr)
+		}
+		step = uint(n)
+		star = false
+	}
+
+	if start < f.min || end > f.max || start > end {
+		return 0, fmt.Errorf("%q out of range [%d, %d]", expr, f.min, f.max)
+	}
+
+	var bits uint64
+	for v := start; v <= end; v += step {
+		bits |= 1 << v
+	}
+	if star {
+		bits |= starBit
+	}
+	return bits, nil
+}
+
+func (f cronField) value(s string) (uint, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	n, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, errors.New("invalid value " + strconv.Quote(s))
+	}
+	return uint(n), nil
+}
+
+// 计算 t 之后的下次执行时间，5年内没有匹配时返回零值
+func (s *cronSpec) next(t time.Time) time.Time {
+	origin := t.Location()
+	t = t.In(s.loc)
+
+	// 从下一秒开始
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	yearLimit := t.Year() + 5
+
+	// 进位到更高的字段时，低位字段从最小值开始
+	added := false
+
+WRAP:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for 1<<uint(t.Month())&s.month == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, s.loc)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto WRAP
+		}
+	}
+
+	for !s.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc)
+		}
+		t = t.AddDate(0, 0, 1)
+		// 夏令时切换可能导致零点不存在
+		if t.Hour() != 0 {
+			if t.Hour() > 12 {
+				t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
+			} else {
+				t = t.Add(-time.Duration(t.Hour()) * time.Hour)
+			}
+		}
+		if t.Day() == 1 {
+			goto WRAP
+		}
+	}
+
+	for 1<<uint(t.Hour())&s.hour == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, s.loc)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto WRAP
+		}
+	}
+
+	for 1<<uint(t.Minute())&s.minute == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto WRAP
+		}
+	}
+
+	for 1<<uint(t.Second())&s.second == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Second)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto WRAP
+		}
+	}
+
+	return t.In(origin)
+}
+
+// 日、周都有限定时，满足其一即可；否则需同时满足
+func (s *cronSpec) dayMatches(t time.Time) bool {
+	domMatch := 1<<uint(t.Day())&s.dom > 0
+	dowMatch := 1<<uint(t.Weekday())&s.dow > 0
+	if s.dom&starBit > 0 || s.dow&starBit > 0 {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
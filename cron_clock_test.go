@@ -0,0 +1,56 @@
+package hub_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goSeeFuture/hub"
+	"github.com/goSeeFuture/hub/hubtest"
+)
+
+func Test_ScheduleMissed(t *testing.T) {
+	cases := []struct {
+		name   string
+		spec   string
+		policy hub.MissedPolicy
+		pause  time.Duration // 一次拨动的时间，跨过多个执行时间
+		runs   int
+		next   time.Duration // 拨动后下次执行时间，相对起始时间
+	}{
+		{"MissedSkip", "@every 1s", hub.MissedSkip, 5 * time.Second, 1, 6 * time.Second},
+		{"MissedCatchUp", "@every 1s", hub.MissedCatchUp, 5 * time.Second, 5, 6 * time.Second},
+		{"MissedCatchUp上限", "@every 10ms", hub.MissedCatchUp, 20 * time.Second, 1024, 20*time.Second + 10*time.Millisecond},
+		{"cron表达式", "*/10 * * * * *", hub.MissedCatchUp, time.Minute, 6, time.Minute + 10*time.Second},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			start := time.Date(2021, 3, 5, 10, 0, 0, 0, time.UTC)
+			clock := hubtest.NewFakeClock(start)
+			g := hub.NewGroup(hub.GroupClock(clock))
+			defer g.Stop()
+
+			var runs int
+			j, err := g.Schedule(c.spec, func() { runs++ },
+				hub.ScheduleMissed(c.policy), hub.ScheduleLocation(time.UTC))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			clock.Advance(c.pause)
+			hubtest.Flush(g)
+			if runs != c.runs {
+				t.Fatalf("want %d runs, got %d", c.runs, runs)
+			}
+			if next := j.Next(); !next.Equal(start.Add(c.next)) {
+				t.Fatalf("next %v", next)
+			}
+
+			// 之后按时执行
+			clock.Advance(c.next - c.pause)
+			hubtest.Flush(g)
+			if runs != c.runs+1 {
+				t.Fatalf("want %d runs, got %d", c.runs+1, runs)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package hub
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_parseCron(t *testing.T) {
+	loc := time.UTC
+	from := time.Date(2021, 3, 5, 10, 20, 30, 0, loc) // 周五
+
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2021, 3, 5, 10, 21, 0, 0, loc)},
+		{"* * * * * *", time.Date(2021, 3, 5, 10, 20, 31, 0, loc)},
+		{"*/15 * * * *", time.Date(2021, 3, 5, 10, 30, 0, 0, loc)},
+		{"0 9-17/4 * * *", time.Date(2021, 3, 5, 13, 0, 0, 0, loc)},
+		{"30 8 * * mon,wed", time.Date(2021, 3, 8, 8, 30, 0, 0, loc)},
+		{"0 0 1 * *", time.Date(2021, 4, 1, 0, 0, 0, 0, loc)},
+		{"0 0 31 * *", time.Date(2021, 3, 31, 0, 0, 0, 0, loc)},
+		{"0 0 29 feb *", time.Date(2024, 2, 29, 0, 0, 0, 0, loc)},
+		{"0 0 13 * 7", time.Date(2021, 3, 7, 0, 0, 0, 0, loc)}, // 日、周满足其一
+		{"@hourly", time.Date(2021, 3, 5, 11, 0, 0, 0, loc)},
+		{"@daily", time.Date(2021, 3, 6, 0, 0, 0, 0, loc)},
+		{"@weekly", time.Date(2021, 3, 7, 0, 0, 0, 0, loc)},
+		{"@yearly", time.Date(2022, 1, 1, 0, 0, 0, 0, loc)},
+		{"@every 5m", from.Add(5 * time.Minute)},
+		{"CRON_TZ=Asia/Shanghai 0 0 * * *", time.Date(2021, 3, 5, 16, 0, 0, 0, loc)},
+	}
+
+	for _, test := range tests {
+		s, err := parseCron(test.spec, loc)
+		if err != nil {
+			t.Fatalf("%s: %v", test.spec, err)
+		}
+		if next := s.next(from); !next.Equal(test.next) {
+			t.Errorf("%s: want %v, got %v", test.spec, test.next, next)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "@often", "@every -1s", "TZ=Nowhere/City * * * * *"} {
+		if _, err := parseCron(spec, loc); err == nil {
+			t.Errorf("%q: want error", spec)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package hub
 
 import (
 	"sync/atomic"
+	"time"
 )
 
 type IDataProcessor interface {
@@ -13,6 +14,40 @@ type IDataProcessor interface {
 	OnData(data interface{}) interface{}
 }
 
+// 带来源信息的数据
+type Envelope struct {
+	Producer interface{} // 来源通道
+	Meta     interface{} // AttachWithMeta 指定的元数据，如连接ID
+	Time     time.Time   // 接收时间
+	Seq      uint64      // 在来源通道中的接收序号，从1开始
+	Data     interface{} // 前一个处理器返回的数据
+}
+
+// 可选接口，处理器实现后由 OnEnvelope 代替 OnData 处理数据
+type IEnvelopeProcessor interface {
+	IDataProcessor
+	// 同 OnData，返回值交给后续处理器，返回nil表示处理结束
+	OnEnvelope(env *Envelope) interface{}
+}
+
+// 可选接口，Group 启用 GroupBatch 后，处理器由 OnBatch 一次处理同一通道的多条数据
+//
+// 未启用 GroupBatch、内部通道的数据和 ProducerClosed 仍由 OnData 处理
+type IBatchProcessor interface {
+	IDataProcessor
+	// 处理一批数据，返回未处理的数据交给后续处理器，返回空表示处理结束
+	// 	batch 只在调用期间有效，需要保留时应复制
+	OnBatch(batch []interface{}) []interface{}
+}
+
+// 监听的通道关闭后，经处理链送出，之后不再监听该通道
+//
+// 调用 Detach 主动移除的通道不会送出
+type ProducerClosed struct {
+	Producer interface{} // 已关闭的通道
+	Meta     interface{} // AttachWithMeta 指定的元数据
+}
+
 // 数据处理器队列，不支持多线程写
 type Queue struct {
 	handlers atomic.Value
@@ -1,6 +1,7 @@
 package hub
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"testing"
@@ -93,3 +94,54 @@ func Test_Queue(t *testing.T) {
 	}()
 	time.Sleep(time.Second)
 }
+
+// 记录收到的 Envelope
+type tEnvelopeProcessor struct {
+	envs []Envelope
+}
+
+func (*tEnvelopeProcessor) Name() string {
+	return "tEnvelopeProcessor"
+}
+
+func (p *tEnvelopeProcessor) OnData(data interface{}) interface{} {
+	panic("OnData called")
+}
+
+func (p *tEnvelopeProcessor) OnEnvelope(env *Envelope) interface{} {
+	p.envs = append(p.envs, *env)
+	return nil
+}
+
+func Test_Envelope(t *testing.T) {
+	for _, e := range engines {
+		t.Run(e.name, func(t *testing.T) {
+			p := &tEnvelopeProcessor{}
+			g := NewGroup(GroupEngine(e.engine), GroupHandles(&Handle1{}, p))
+			conn1, conn2 := make(chan interface{}), make(chan interface{})
+			g.AttachWithMeta(conn1, "conn-1")
+			g.AttachWithMeta(conn2, "conn-2")
+
+			conn1 <- "a"
+			conn2 <- "b"
+			conn1 <- "c"
+			if err := g.Shutdown(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(p.envs) != 3 {
+				t.Fatalf("envs %+v", p.envs)
+			}
+			// 不同通道之间的处理顺序不确定，按通道检查
+			want := map[interface{}][]string{conn1: {"a", "c"}, conn2: {"b"}}
+			meta := map[interface{}]string{conn1: "conn-1", conn2: "conn-2"}
+			for _, env := range p.envs {
+				seq := int(env.Seq)
+				if seq < 1 || seq > len(want[env.Producer]) || env.Data != want[env.Producer][seq-1] ||
+					env.Meta != meta[env.Producer] || env.Time.IsZero() {
+					t.Fatalf("env %+v", env)
+				}
+			}
+		})
+	}
+}
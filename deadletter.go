@@ -0,0 +1,118 @@
+package hub
+
+import (
+	"sync"
+	"time"
+)
+
+// 死信原因
+type DeadReason int
+
+const (
+	// 经过所有处理器后仍未被处理
+	DeadUnhandled DeadReason = iota + 1
+	// 事件或调用没有注册处理函数
+	DeadUnregistered
+	// 处理时发生 panic
+	DeadPanic
+	// group 已停止，数据未被处理
+	DeadGroupStopped
+	// 缓冲已满被丢弃，或 SendTo 的目标通道已关闭
+	DeadOverflow
+)
+
+func (r DeadReason) String() string {
+	switch r {
+	case DeadUnhandled:
+		return "unhandled"
+	case DeadUnregistered:
+		return "unregistered"
+	case DeadPanic:
+		return "panic"
+	case DeadGroupStopped:
+		return "group stopped"
+	case DeadOverflow:
+		return "overflow"
+	}
+	return "unknown"
+}
+
+// 未能处理的数据
+type DeadLetter struct {
+	Reason DeadReason
+	Group  string
+	// 事件或调用名称，如 event:login、call:query、SlowCall、SendTo，来自通道的数据为空
+	Name string
+	// 数据来源通道，通过 Emit、Call、SlowCall 投递时为 nil，SendTo 的数据为目标通道
+	Producer interface{}
+	Data     interface{}
+	Time     time.Time
+}
+
+// 死信接收者，Put 可能在 group 协程或调用方协程中调用，不应阻塞
+type DeadLetterSink interface {
+	Put(letter DeadLetter)
+}
+
+// 保存最近死信的环形缓冲，超出容量时覆盖最早的死信
+type DeadLetterRing struct {
+	mu      sync.Mutex
+	letters []DeadLetter
+	start   int
+	n       int
+	total   int
+}
+
+// 构建容量为 size 的环形缓冲
+func NewDeadLetterRing(size int) *DeadLetterRing {
+	if size <= 0 {
+		size = 1
+	}
+	return &DeadLetterRing{letters: make([]DeadLetter, size)}
+}
+
+// 保存死信
+func (r *DeadLetterRing) Put(letter DeadLetter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.total++
+	if r.n < len(r.letters) {
+		r.letters[(r.start+r.n)%len(r.letters)] = letter
+		r.n++
+		return
+	}
+
+	r.letters[r.start] = letter
+	r.start = (r.start + 1) % len(r.letters)
+}
+
+// 缓冲中的死信，从早到晚排列
+func (r *DeadLetterRing) Letters() []DeadLetter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	letters := make([]DeadLetter, r.n)
+	for i := range letters {
+		letters[i] = r.letters[(r.start+i)%len(r.letters)]
+	}
+	return letters
+}
+
+// 收到的死信总数，包括已被覆盖的
+func (r *DeadLetterRing) Total() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.total
+}
+
+// 清空缓冲
+func (r *DeadLetterRing) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.letters {
+		r.letters[i] = DeadLetter{}
+	}
+	r.start, r.n, r.total = 0, 0, 0
+}
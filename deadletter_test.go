@@ -0,0 +1,116 @@
+package hub
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_DeadLetterRing(t *testing.T) {
+	r := NewDeadLetterRing(2)
+	for i := 1; i <= 3; i++ {
+		r.Put(DeadLetter{Data: i})
+	}
+
+	letters := r.Letters()
+	if len(letters) != 2 || letters[0].Data != 2 || letters[1].Data != 3 || r.Total() != 3 {
+		t.Fatalf("unexpected %v", letters)
+	}
+
+	r.Reset()
+	if len(r.Letters()) != 0 || r.Total() != 0 {
+		t.Fatal("not reset")
+	}
+}
+
+func Test_DeadLetter(t *testing.T) {
+	t.Run("未处理", func(t *testing.T) {
+		ring := NewDeadLetterRing(8)
+		g := NewGroup(GroupDeadLetter(ring))
+		ch := make(chan interface{})
+		g.Attach(ch)
+		ch <- "lost"
+		g.Shutdown(context.Background())
+
+		letters := ring.Letters()
+		if len(letters) != 1 || letters[0].Reason != DeadUnhandled || letters[0].Data != "lost" ||
+			letters[0].Producer != ch || letters[0].Group != g.Name() {
+			t.Fatalf("unexpected %+v", letters)
+		}
+	})
+
+	t.Run("未注册", func(t *testing.T) {
+		ring := NewDeadLetterRing(8)
+		g := NewGroup(GroupDeadLetter(ring))
+		defer g.Stop()
+
+		g.Emit("nobody", 1)
+		g.Call("nobody", 2)
+
+		letters := ring.Letters()
+		if len(letters) != 2 ||
+			letters[0].Reason != DeadUnregistered || letters[0].Name != "event:nobody" || letters[0].Data != 1 ||
+			letters[1].Reason != DeadUnregistered || letters[1].Name != "call:nobody" || letters[1].Data != 2 {
+			t.Fatalf("unexpected %+v", letters)
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		ring := NewDeadLetterRing(8)
+		g := NewGroup(GroupDeadLetter(ring), GroupRecovery(-1), GroupPanicHandler(func(info PanicInfo) bool {
+			return true
+		}))
+		g.ListenEvent("boom", func(arg interface{}) {
+			panic(arg)
+		})
+		g.Emit("boom", 1)
+		g.Shutdown(context.Background())
+
+		letters := ring.Letters()
+		if len(letters) != 1 || letters[0].Reason != DeadPanic || letters[0].Name != "event:boom" ||
+			letters[0].Data != 1 || letters[0].Producer != nil {
+			t.Fatalf("unexpected %+v", letters)
+		}
+	})
+
+	t.Run("已停止", func(t *testing.T) {
+		ring := NewDeadLetterRing(8)
+		g := NewGroup(GroupDeadLetter(ring))
+		g.ListenEvent("e", func(arg interface{}) {})
+		g.Stop()
+		<-g.Done()
+
+		g.Emit("e", 1)
+		letters := ring.Letters()
+		if len(letters) != 1 || letters[0].Reason != DeadGroupStopped || letters[0].Name != "event:e" || letters[0].Data != 1 {
+			t.Fatalf("unexpected %+v", letters)
+		}
+	})
+
+	t.Run("退出时通道中剩余", func(t *testing.T) {
+		ring := NewDeadLetterRing(8)
+		g := NewGroup(GroupDeadLetter(ring), GroupPanicHandler(func(info PanicInfo) bool {
+			return false
+		}))
+		ch := make(chan interface{}, 2)
+		g.Attach(ch)
+
+		started, crash := make(chan struct{}), make(chan struct{})
+		g.ListenEvent("crash", func(arg interface{}) {
+			close(started)
+			<-crash
+			panic("crash")
+		})
+		g.Emit("crash", nil)
+		<-started
+		ch <- 1
+		ch <- 2
+		close(crash)
+		<-g.Done()
+
+		letters := ring.Letters()
+		if len(letters) != 2 || letters[0].Reason != DeadGroupStopped || letters[0].Producer != ch ||
+			letters[0].Data != 1 || letters[1].Data != 2 {
+			t.Fatalf("unexpected %+v", letters)
+		}
+	})
+}
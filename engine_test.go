@@ -0,0 +1,215 @@
+package hub
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var engines = []struct {
+	name   string
+	engine Engine
+}{
+	{"select", EngineSelect},
+	{"funnel", EngineFunnel},
+}
+
+// 计数处理器，收到 n 个数据后关闭 done
+type tCountProcessor struct {
+	n     int64
+	count int64
+	done  chan struct{}
+}
+
+func (*tCountProcessor) Name() string {
+	return "tCountProcessor"
+}
+
+func (p *tCountProcessor) OnData(data interface{}) interface{} {
+	if atomic.AddInt64(&p.count, 1) == p.n {
+		close(p.done)
+	}
+	return nil
+}
+
+func Test_Engine(t *testing.T) {
+	for _, e := range engines {
+		t.Run(e.name, func(t *testing.T) {
+			t.Run("聚合与移除", func(t *testing.T) {
+				p := &tOrderProcessor{}
+				g := NewGroup(GroupEngine(e.engine), GroupHandles(p))
+				ch1, ch2 := make(chan interface{}), make(chan interface{})
+				g.Attach(ch1)
+				g.Attach(ch2)
+
+				ch1 <- 1
+				ch2 <- 2
+				g.Detach(ch2)
+				// 移除前已接收的数据在移除时已处理
+				handled := false
+				p.mu.Lock()
+				for _, v := range p.order {
+					handled = handled || v == 2
+				}
+				p.mu.Unlock()
+				if !handled {
+					t.Fatalf("order %v before detach", p.order)
+				}
+
+				select {
+				case ch2 <- 3:
+					t.Fatal("received after detach")
+				case <-time.After(20 * time.Millisecond):
+				}
+
+				close(ch1)
+				if err := g.Shutdown(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+				if len(p.order) != 3 || p.order[2] != (ProducerClosed{Producer: ch1}) {
+					t.Fatalf("order %v", p.order)
+				}
+			})
+
+			t.Run("类型化通道", func(t *testing.T) {
+				p := &tOrderProcessor{}
+				g := NewGroup(GroupEngine(e.engine), GroupHandles(p))
+				var got []string
+				bytes := make(chan []byte)
+				AttachTyped(g, bytes, func(b []byte) {
+					got = append(got, string(b))
+				})
+				// 非 chan interface{} 通道也可经处理链处理
+				ints := make(chan int)
+				g.Attach((<-chan int)(ints))
+
+				bytes <- []byte("a")
+				ints <- 1
+				bytes <- []byte("b")
+				// 以双向形式移除只读形式添加的通道
+				g.Detach(ints)
+				select {
+				case ints <- 2:
+					t.Fatal("received after detach")
+				case <-time.After(20 * time.Millisecond):
+				}
+
+				close(bytes)
+				if err := g.Shutdown(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+				if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+					t.Fatalf("got %v", got)
+				}
+				// 类型化通道关闭不送出 ProducerClosed
+				if len(p.order) != 1 || p.order[0] != 1 {
+					t.Fatalf("order %v", p.order)
+				}
+			})
+
+			t.Run("通道关闭", func(t *testing.T) {
+				p := &tOrderProcessor{}
+				g := NewGroup(GroupEngine(e.engine), GroupHandles(p))
+				ch := make(chan interface{})
+				g.AttachWithMeta(ch, "session-1")
+				ch <- 1
+				close(ch)
+
+				for len(g.ProducerStats()) != 0 {
+					time.Sleep(time.Millisecond)
+				}
+				if err := g.Shutdown(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+				if len(p.order) != 2 || p.order[1] != (ProducerClosed{Producer: ch, Meta: "session-1"}) {
+					t.Fatalf("order %v", p.order)
+				}
+			})
+
+			t.Run("控制通道关闭", func(t *testing.T) {
+				h := newHub(hubconfig{Engine: e.engine})
+				close(h.producer)
+				<-h.Done()
+				if h.Err() != ErrGroupStopped || h.IsWorking() {
+					t.Fatalf("unexpected %v", h.Err())
+				}
+			})
+
+			t.Run("定时器与慢调用", func(t *testing.T) {
+				g := NewGroup(GroupEngine(e.engine))
+				defer g.Stop()
+
+				fired := make(chan struct{})
+				g.AfterFunc(10*time.Millisecond, func() { close(fired) })
+				<-fired
+
+				ret := make(chan Return, 1)
+				g.SlowCall(func(arg interface{}) Return {
+					return Return{Value: arg}
+				}, 1, func(r Return) { ret <- r })
+				if r := <-ret; r.Value != 1 {
+					t.Fatalf("unexpected %v", r)
+				}
+			})
+
+			t.Run("Shutdown处理排队数据", func(t *testing.T) {
+				p := &tCountProcessor{n: 3, done: make(chan struct{})}
+				g := NewGroup(GroupEngine(e.engine), GroupHandles(p))
+				ch := make(chan interface{}, 3)
+				g.Attach(ch)
+
+				block := make(chan struct{})
+				g.ListenEvent("block", func(interface{}) { <-block })
+				g.Emit("block", nil)
+				ch <- 1
+				ch <- 2
+				ch <- 3
+
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					close(block)
+				}()
+				if err := g.Shutdown(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+				if atomic.LoadInt64(&p.count) != 3 {
+					t.Fatalf("handled %d", p.count)
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkEngine(b *testing.B) {
+	for _, e := range engines {
+		for _, n := range []int{10, 1000, 50000} {
+			b.Run(e.name+"/"+strconv.Itoa(n), func(b *testing.B) {
+				benchmarkEngine(b, e.engine, n)
+			})
+		}
+	}
+}
+
+func benchmarkEngine(b *testing.B, engine Engine, producers int) {
+	p := &tCountProcessor{n: int64(b.N), done: make(chan struct{})}
+	g := NewGroup(GroupEngine(engine), GroupHandles(p))
+	defer g.Stop()
+
+	// 在 group 协程中批量添加，避免逐个 Attach 的等待
+	chans := make([]chan interface{}, producers)
+	g.runInGroup(context.Background(), func() {
+		for i := range chans {
+			chans[i] = make(chan interface{}, 1)
+			g.hub.attach(chans[i], producerInfo{})
+		}
+	})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		chans[i%producers] <- i
+	}
+	<-p.done
+}
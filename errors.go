@@ -0,0 +1,24 @@
+package hub
+
+import "errors"
+
+var (
+	// 参数或返回值类型与注册的处理函数不一致
+	ErrTypeMismatch = errors.New("hub: type mismatch")
+	// 事件或调用没有注册处理函数
+	ErrNotRegistered = errors.New("hub: handler not registered")
+	// group 已停止或正在停止，不再接收新的调用
+	ErrGroupStopped = errors.New("hub: group stopped")
+	// 缓冲已满
+	ErrOverflow = errors.New("hub: buffer overflow")
+	// SendTo 的目标通道已关闭
+	ErrChanClosed = errors.New("hub: send on closed channel")
+	// SendTo 的目标通道为 nil
+	ErrNilChan = errors.New("hub: send on nil channel")
+	// 在 group 协程中调用了会等待 group 协程的方法，如在处理函数中调用 Shutdown
+	ErrInGroup = errors.New("hub: called on the group goroutine")
+	// 在 group 协程外调用了只能在 group 协程中调用的方法，如 SendTo
+	ErrNotInGroup = errors.New("hub: called outside the group goroutine")
+	// 监管的 Group 重启次数超出限制
+	ErrRestartIntensity = errors.New("hub: restart intensity exceeded")
+)
@@ -1,21 +1,46 @@
 package hub
 
+import (
+	"context"
+	"time"
+)
+
 type asyncEventCall struct {
-	out  chan interface{}
-	exec func()
+	ctx    context.Context
+	name   string
+	arg    interface{}
+	out    chan interface{}
+	exec   func() error // 返回处理函数的 Return.Error
+	queued time.Time    // 进入收件箱的时间
 }
 
-func newEventAsyncCall(out chan interface{}, fn func(arg interface{}) Return, arg interface{}) asyncEventCall {
+// 构建跨协程调用，out 由执行方写入返回值后关闭
+//
+// 调用方已放弃等待（ctx 取消或超时）时，不再执行 fn
+func newEventAsyncCall(ctx context.Context, name string, out chan interface{}, fn func(arg interface{}) Return, arg interface{}) asyncEventCall {
 
 	return asyncEventCall{
-		out: out,
-		exec: func() {
-			out <- asyncReturn(fn(arg))
+		ctx:    ctx,
+		name:   name,
+		arg:    arg,
+		out:    out,
+		queued: time.Now(),
+		exec: func() error {
+			defer close(out)
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			ret := fn(arg)
+			out <- asyncReturn(ret)
+			return ret.Error
 		},
 	}
 }
 
 type eventCall struct {
-	exec func(arg interface{})
-	arg  interface{}
+	ctx    context.Context
+	name   string
+	exec   func(arg interface{})
+	arg    interface{}
+	queued time.Time // 进入收件箱的时间
 }
@@ -0,0 +1,111 @@
+package hub
+
+import "reflect"
+
+// 通道的接收统计
+type ProducerStat struct {
+	Producer interface{}
+	Level    int     // 优先级
+	Weight   int     // 公平调度权重
+	Received uint64  // 已接收的数据数量
+	Share    float64 // 在同一优先级通道中的接收占比
+}
+
+// 按差额轮询（DRR）从最低优先级通道中取一条数据，没有就绪数据时返回 -1
+//
+// 每轮每个通道获得 quantum*weight 的额度，每条数据消耗1，
+// 通道为空时清零额度，轮到下一个通道
+func (ps *producerSet) fair(quantum int) (int, reflect.Value, bool) {
+	if ps.dirty {
+		ps.build()
+	}
+
+	// 最多轮询一圈，全部为空时返回
+	for visited := 0; visited <= len(ps.cases); {
+		if ps.cursor < firstProducer || ps.cursor >= len(ps.cases) ||
+			ps.infos[ps.cursor].deficit <= 0 || ps.infos[ps.cursor].level != ps.lowest {
+			if !ps.advance(quantum) {
+				return -1, reflect.Value{}, false
+			}
+			visited++
+		}
+
+		i := ps.cursor
+		recv, ok := ps.cases[i].Chan.TryRecv()
+		if !recv.IsValid() {
+			// 通道为空
+			ps.infos[i].deficit = 0
+			continue
+		}
+
+		ps.infos[i].deficit--
+		return i, recv, ok
+	}
+	return -1, reflect.Value{}, false
+}
+
+// 轮到下一个最低优先级通道，并增加其额度，没有可调度的通道时返回 false
+func (ps *producerSet) advance(quantum int) bool {
+	n := len(ps.cases) - firstProducer
+	for k := 0; k < n; k++ {
+		ps.cursor++
+		if ps.cursor < firstProducer || ps.cursor >= len(ps.cases) {
+			ps.cursor = firstProducer
+		}
+
+		info := &ps.infos[ps.cursor]
+		if info.level == ps.lowest {
+			info.deficit += quantum * info.weight
+			return true
+		}
+	}
+	return false
+}
+
+// 公平调度只作用于最低优先级的通道，附加的通道与已有通道优先级不同时警告一次，只能在 hub 协程中调用
+func (h *Hub) checkFair(level int) {
+	if h.warned {
+		return
+	}
+	for i := firstProducer; i < len(h.ps.infos); i++ {
+		if other := h.ps.infos[i].level; other != priorityControl && other != level {
+			h.warned = true
+			h.log.warn("GroupFair only applies to the lowest priority channels", "level", level, "other", other)
+			return
+		}
+	}
+}
+
+// 各通道的接收统计，不含内部控制通道，只能在 hub 协程中调用
+func (h *Hub) producerStats() []ProducerStat {
+	var stats []ProducerStat
+	if h.engine == EngineFunnel {
+		for _, e := range h.fn.entries {
+			stats = append(stats, ProducerStat{Producer: e.producer, Level: e.level, Weight: e.weight, Received: e.received})
+		}
+	} else {
+		for i := firstProducer; i < len(h.ps.cases); i++ {
+			info := h.ps.infos[i]
+			stats = append(stats, ProducerStat{Producer: h.ps.cases[i].Chan.Interface(), Level: info.level, Weight: info.weight, Received: info.received})
+		}
+	}
+
+	// 统计同一优先级的总数
+	n := 0
+	totals := make(map[int]uint64)
+	for _, st := range stats {
+		if st.Level != priorityControl {
+			totals[st.Level] += st.Received
+			stats[n] = st
+			n++
+		}
+	}
+	stats = stats[:n]
+
+	for i := range stats {
+		if total := totals[stats[i].Level]; total > 0 {
+			stats[i].Share = float64(stats[i].Received) / float64(total)
+		}
+	}
+	return stats
+}
@@ -0,0 +1,74 @@
+package hub
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_Fair(t *testing.T) {
+	p := &tOrderProcessor{}
+	g := NewGroup(GroupFair(1), GroupHandles(p))
+	a, b := make(chan interface{}, 400), make(chan interface{}, 400)
+	g.AttachWeighted(a, 1)
+	g.AttachWeighted(b, 3)
+
+	started, block := make(chan struct{}), make(chan struct{})
+	g.ListenEvent("block", func(interface{}) {
+		close(started)
+		<-block
+	})
+	g.Emit("block", nil)
+	<-started
+	for i := 0; i < 400; i++ {
+		a <- "a"
+		b <- "b"
+	}
+	close(block)
+
+	// 等待全部处理
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		stats := g.ProducerStats()
+		if len(stats) == 2 && stats[0].Received+stats[1].Received == 800 {
+			if stats[0].Weight != 1 || stats[1].Weight != 3 || stats[0].Share != 0.5 {
+				t.Fatalf("stats %+v", stats)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stats %+v", stats)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	counts := map[interface{}]int{}
+	for _, v := range p.order[:400] {
+		counts[v]++
+	}
+	if counts["a"] != 100 || counts["b"] != 300 {
+		t.Fatalf("first 400: %v", counts)
+	}
+
+	if err := g.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_FairWarning(t *testing.T) {
+	t.Run("EngineFunnel", func(t *testing.T) {
+		logs := make(chan string, 64)
+		g := NewGroup(GroupFair(1), GroupEngine(EngineFunnel), GroupLogger(tLogHandler{logs}))
+		defer g.Stop()
+		waitLog(t, logs, "GroupFair has no effect with EngineFunnel")
+	})
+
+	t.Run("不同优先级", func(t *testing.T) {
+		logs := make(chan string, 64)
+		g := NewGroup(GroupFair(1), GroupLogger(tLogHandler{logs}))
+		defer g.Stop()
+		g.AttachWeighted(make(chan int), 2)
+		g.AttachPriority(make(chan int), 1)
+		waitLog(t, logs, "GroupFair only applies to the lowest priority channels")
+	})
+}
@@ -0,0 +1,402 @@
+package hub
+
+import (
+	"reflect"
+	"sort"
+)
+
+// funnel 引擎，每个通道一个转发协程，经所在优先级的无缓冲通道汇聚到 hub 协程
+//
+// 汇聚通道无缓冲，hub 协程收到前数据不会离开转发协程，
+// 停止转发协程时最多取回一条已接收未处理的数据
+type funnel struct {
+	ctrl    chan funnelMsg // 内部控制通道的汇聚通道
+	tiers   []funnelTier   // 其他优先级的汇聚通道，从高到低
+	wait    []reflect.SelectCase
+	entries []*funnelEntry
+}
+
+type funnelTier struct {
+	level int
+	in    chan funnelMsg
+}
+
+type funnelEntry struct {
+	producerInfo
+	producer interface{}
+	ch       reflect.Value
+	in       chan funnelMsg // 所在优先级的汇聚通道
+	removed  bool
+
+	running bool
+	stop    chan struct{}
+	done    chan struct{} // 转发协程退出后关闭
+	left    interface{}   // 转发协程停止时已接收未送出的数据
+	hasLeft bool
+}
+
+type funnelMsg struct {
+	entry  *funnelEntry
+	data   interface{}
+	closed bool // 通道已关闭
+}
+
+func (f *funnel) init() {
+	f.ctrl = make(chan funnelMsg)
+}
+
+// 添加通道并启动转发协程
+func (f *funnel) attach(producer interface{}, info producerInfo) {
+	e := &funnelEntry{
+		producerInfo: info,
+		producer:     producer,
+		ch:           reflect.ValueOf(producer),
+		in:           f.tier(info.level),
+	}
+	f.entries = append(f.entries, e)
+	f.start(e)
+}
+
+// level 优先级的汇聚通道
+func (f *funnel) tier(level int) chan funnelMsg {
+	if level == priorityControl {
+		return f.ctrl
+	}
+
+	i := 0
+	for ; i < len(f.tiers); i++ {
+		if f.tiers[i].level == level {
+			return f.tiers[i].in
+		}
+		if f.tiers[i].level < level {
+			break
+		}
+	}
+
+	t := funnelTier{level: level, in: make(chan funnelMsg)}
+	f.tiers = append(f.tiers[:i], append([]funnelTier{t}, f.tiers[i:]...)...)
+	f.wait = nil
+	return t.in
+}
+
+// 按优先级从高到低非阻塞检查，最低优先级不检查
+func (f *funnel) poll() (funnelMsg, bool) {
+	for i := 0; i < len(f.tiers)-1; i++ {
+		select {
+		case m := <-f.tiers[i].in:
+			return m, true
+		default:
+		}
+	}
+	return funnelMsg{}, false
+}
+
+func (f *funnel) indexOf(producer interface{}) int {
+	for i, e := range f.entries {
+		if sameProducer(e.producer, producer) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *funnel) remove(e *funnelEntry) {
+	for i, x := range f.entries {
+		if x == e {
+			f.entries = append(f.entries[:i], f.entries[i+1:]...)
+			break
+		}
+	}
+	e.removed = true
+}
+
+func (f *funnel) start(e *funnelEntry) {
+	e.running = true
+	e.stop = make(chan struct{})
+	e.done = make(chan struct{})
+	e.left, e.hasLeft = nil, false
+	go e.forward(e.in)
+}
+
+// 停止转发协程，返回已接收未处理的数据
+func (f *funnel) halt(e *funnelEntry) (interface{}, bool) {
+	if !e.running {
+		return nil, false
+	}
+
+	e.running = false
+	close(e.stop)
+	<-e.done
+	return e.left, e.hasLeft
+}
+
+// 停止所有转发协程
+func (f *funnel) haltAll() []funnelMsg {
+	var left []funnelMsg
+	for _, e := range f.entries {
+		if data, ok := f.halt(e); ok {
+			left = append(left, funnelMsg{entry: e, data: data})
+		}
+	}
+	return left
+}
+
+// 转发协程，接收通道数据送到 in，直到停止或通道关闭
+func (e *funnelEntry) forward(in chan<- funnelMsg) {
+	defer close(e.done)
+
+	for {
+		data, ok, stopped := e.recv()
+		if stopped {
+			return
+		}
+
+		select {
+		case in <- funnelMsg{entry: e, data: data, closed: !ok}:
+			if !ok {
+				return
+			}
+		case <-e.stop:
+			e.left, e.hasLeft = data, ok
+			return
+		}
+	}
+}
+
+func (e *funnelEntry) recv() (data interface{}, ok, stopped bool) {
+	if ch, isChan := e.producer.(chan interface{}); isChan {
+		select {
+		case data, ok = <-ch:
+			return data, ok, false
+		case <-e.stop:
+			return nil, false, true
+		}
+	}
+
+	chosen, recv, ok := reflect.Select([]reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: e.ch},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(e.stop)},
+	})
+	if chosen == 1 {
+		return nil, false, true
+	}
+	if !ok {
+		return nil, false, false
+	}
+	return recv.Interface(), true, false
+}
+
+// 汇聚所有转发协程的数据，停止时返回
+func (h *Hub) loopFunnel() {
+	// panic 恢复后，继续 drain 时暂停的转发协程
+	for _, e := range h.fn.entries {
+		if !e.running {
+			h.fn.start(e)
+		}
+	}
+
+	for {
+		h.idle()
+		// 控制通道优先
+		select {
+		case op, ok := <-h.producer:
+			if !ok {
+				h.stop(ErrGroupStopped)
+				return
+			}
+			h.control(op)
+			continue
+		case <-h.quit:
+			return
+		case m := <-h.fn.ctrl:
+			h.receive(m)
+			continue
+		default:
+		}
+
+		if m, ok := h.fn.poll(); ok {
+			h.receive(m)
+			continue
+		}
+
+		n := len(h.fn.tiers)
+		if h.out.pending() {
+			// 有待发送数据时，使用 reflect.Select 同时等待目标通道可写
+			n = -1
+		}
+
+		switch n {
+		case 0:
+			select {
+			case op, ok := <-h.producer:
+				if !ok {
+					h.stop(ErrGroupStopped)
+					return
+				}
+				h.control(op)
+			case <-h.quit:
+				return
+			case m := <-h.fn.ctrl:
+				h.receive(m)
+			}
+		case 1:
+			select {
+			case op, ok := <-h.producer:
+				if !ok {
+					h.stop(ErrGroupStopped)
+					return
+				}
+				h.control(op)
+			case <-h.quit:
+				return
+			case m := <-h.fn.ctrl:
+				h.receive(m)
+			case m := <-h.fn.tiers[0].in:
+				h.receive(m)
+			}
+		default:
+			if h.fn.wait == nil {
+				h.fn.wait = []reflect.SelectCase{
+					{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(h.producer)},
+					{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(h.quit)},
+					{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(h.fn.ctrl)},
+				}
+				for _, t := range h.fn.tiers {
+					h.fn.wait = append(h.fn.wait, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(t.in)})
+				}
+			}
+
+			var (
+				chosen int
+				recv   reflect.Value
+				ok     bool
+			)
+			if h.out.pending() {
+				chosen, recv, ok = h.selectOut(h.fn.wait)
+			} else {
+				chosen, recv, ok = reflect.Select(h.fn.wait)
+			}
+			switch {
+			case chosen == -1:
+				// 移除了已关闭的目标通道
+			case chosen >= len(h.fn.wait):
+				h.out.sent(chosen - len(h.fn.wait))
+			case chosen == controlCase:
+				if !ok {
+					h.stop(ErrGroupStopped)
+					return
+				}
+				h.control(recv.Interface().(producerOp))
+			case chosen == quitCase:
+				return
+			default:
+				h.receive(recv.Interface().(funnelMsg))
+			}
+		}
+	}
+}
+
+// 处理转发协程送来的数据
+func (h *Hub) receive(m funnelMsg) {
+	if m.closed {
+		// remove close chan
+		h.fn.remove(m.entry)
+		h.closed(&m.entry.producerInfo, m.entry.producer)
+		return
+	}
+	if h.batchSize > 1 && m.entry.batchable() {
+		h.batchFunnel(m.entry, m.data)
+		return
+	}
+	h.dispatch(&m.entry.producerInfo, m.entry.producer, m.data)
+}
+
+// 移除通道，转发协程已接收的数据在移除前处理
+func (h *Hub) detachFunnel(producer interface{}) bool {
+	i := h.fn.indexOf(producer)
+	if i == -1 {
+		return false
+	}
+
+	e := h.fn.entries[i]
+	h.countProducer(&e.producerInfo, -1)
+	h.fn.remove(e)
+	if data, ok := h.fn.halt(e); ok {
+		h.dispatch(&e.producerInfo, e.producer, data)
+	}
+	return true
+}
+
+// 暂停转发协程，处理已接收的数据和通道中已排队的数据，然后恢复转发
+func (h *Hub) drainFunnel() {
+	type queued struct {
+		e *funnelEntry
+		n int
+	}
+
+	entries := append([]*funnelEntry(nil), h.fn.entries...)
+	left := h.fn.haltAll()
+
+	var chans []queued
+	for _, e := range entries {
+		// 多尝试接收一次，以发现已关闭的通道
+		n := e.ch.Len() + 1
+		chans = append(chans, queued{e, n})
+	}
+
+	// 高优先级先处理
+	sort.SliceStable(left, func(i, j int) bool { return left[i].entry.level > left[j].entry.level })
+	sort.SliceStable(chans, func(i, j int) bool { return chans[i].e.level > chans[j].e.level })
+
+	batching := make(map[*funnelEntry]interface{})
+	for _, m := range left {
+		if m.entry.removed {
+			continue
+		}
+		if h.batchSize > 1 && m.entry.batchable() {
+			// 与通道中已排队的数据一起批量处理
+			batching[m.entry] = m.data
+			continue
+		}
+		h.dispatch(&m.entry.producerInfo, m.entry.producer, m.data)
+	}
+
+	for _, q := range chans {
+		if e := q.e; h.batchSize > 1 && e.batchable() {
+			batch := h.batch[:0]
+			if data, ok := batching[e]; ok {
+				batch = append(batch, data)
+			}
+			closed := h.drainBatch(e.ch, q.n, batch, func() *producerInfo {
+				if e.removed {
+					return nil
+				}
+				return &e.producerInfo
+			})
+			if closed && !e.removed {
+				h.fn.remove(e)
+				h.closed(&e.producerInfo, e.producer)
+			}
+			continue
+		}
+
+		for n := 0; n < q.n && !q.e.removed; n++ {
+			recv, ok := q.e.ch.TryRecv()
+			if !ok {
+				if recv.IsValid() {
+					// remove close chan
+					h.fn.remove(q.e)
+					h.closed(&q.e.producerInfo, q.e.producer)
+				}
+				break
+			}
+			h.dispatch(&q.e.producerInfo, q.e.producer, recv.Interface())
+		}
+	}
+
+	for _, e := range entries {
+		if !e.removed && !e.running {
+			h.fn.start(e)
+		}
+	}
+}
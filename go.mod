@@ -1,5 +1,5 @@
 module github.com/goSeeFuture/hub
 
-go 1.15
+go 1.21
 
 require github.com/rs/zerolog v1.20.0
@@ -1,14 +1,13 @@
 package hub
 
 import (
-	"os"
+	"context"
+	"errors"
+	"fmt"
 	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
-
-	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
 )
 
 const groupChanLen = 12
@@ -17,18 +16,24 @@ var (
 	unnamegroup int64
 )
 
-func init() {
-	zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout})
-}
-
 // Group 监听多个通道，并支持异步串行
 type Group struct {
 	hub         *Hub
 	processChan chan interface{}
 	calls       sync.Map // map[string]func(interface{}) Return
 	events      sync.Map // map[string]func(interface{})
+	types       sync.Map // map[typedKey]reflect.Type 类型化处理函数的参数类型
+	timers      *timerWheel
+	clock       Clock
 	config      groupconfig
+
+	closing   int32          // 1 表示已停止接收新的调用
+	slowCalls pendingCounter // 进行中的 SlowCall
+	hooksMu   sync.Mutex
+	stopHooks []func()
+
+	ctx  context.Context // 正在执行的处理函数的 ctx，只在 group 协程中访问
+	span Span            // 正在执行的处理函数的 span，只在 group 协程中访问
 }
 
 type groupconfig struct {
@@ -36,6 +41,28 @@ type groupconfig struct {
 	Handles    []IDataProcessor
 	ChannelLen int
 	Recovery   int // -1 总是恢复； 0 不恢复； >0 恢复次数
+	TimerTick  time.Duration
+	Clock      Clock
+	// 优雅停止各步骤的进度报告
+	ShutdownReport func(step ShutdownStep, err error)
+	Context        context.Context
+	PanicHandler   func(info PanicInfo) bool
+	DeadLetter     DeadLetterSink
+	Engine         Engine
+	Quantum        int
+	SendLimit      int
+	SendPolicy     OverflowPolicy
+	BatchSize      int
+	BatchWait      time.Duration
+	InboxPolicy    OverflowPolicy
+	InboxTimeout   time.Duration
+	Metrics        MetricsSink
+	Histograms     bool
+	Watchdog       time.Duration
+	WatchRepeat    bool
+	OnSlow         func(WatchdogReport)
+	Logger         Logger
+	Tracer         Tracer
 }
 
 type GroupOption func(gc *groupconfig)
@@ -47,7 +74,7 @@ func GroupName(name string) func(gc *groupconfig) {
 	}
 }
 
-// 数据通道长度
+// 收件箱长度，Emit、Call、SlowCall 在收件箱中排队等待 group 协程处理，默认12
 func GroupChannelLen(channelLen int) func(gc *groupconfig) {
 	return func(gc *groupconfig) {
 		gc.ChannelLen = channelLen
@@ -69,32 +96,236 @@ func GroupRecovery(recovery int) func(gc *groupconfig) {
 	}
 }
 
+// 定时器精度，AfterFunc、Tick 的最小时间单位
+// 默认值10ms
+func GroupTimerTick(tick time.Duration) func(gc *groupconfig) {
+	return func(gc *groupconfig) {
+		gc.TimerTick = tick
+	}
+}
+
+// 定时器、计划任务使用的时钟
+// 默认使用系统时钟，测试中可用 hubtest.FakeClock 手动拨动时间
+func GroupClock(clock Clock) func(gc *groupconfig) {
+	return func(gc *groupconfig) {
+		gc.Clock = clock
+	}
+}
+
+// 优雅停止（Shutdown）各步骤完成或出错时回调
+func GroupShutdownReport(report func(step ShutdownStep, err error)) func(gc *groupconfig) {
+	return func(gc *groupconfig) {
+		gc.ShutdownReport = report
+	}
+}
+
+// ctx 结束时停止 group，Err 返回 ctx.Err()
+func GroupContext(ctx context.Context) func(gc *groupconfig) {
+	return func(gc *groupconfig) {
+		gc.Context = ctx
+	}
+}
+
+// panic 处理，返回是否恢复 group 协程，默认记录日志并按恢复次数恢复
+// 	info.Recover 为按 GroupRecovery 恢复次数的默认决定
+// 	SlowCall 的 fn 在独立协程执行，返回 false 时停止 group，Err 返回 *PanicError
+func GroupPanicHandler(handler func(info PanicInfo) bool) func(gc *groupconfig) {
+	return func(gc *groupconfig) {
+		gc.PanicHandler = handler
+	}
+}
+
+// 死信接收者，接收未处理、未注册、panic 时正在处理以及 group 停止后未处理的数据
+// 默认丢弃
+func GroupDeadLetter(sink DeadLetterSink) func(gc *groupconfig) {
+	return func(gc *groupconfig) {
+		gc.DeadLetter = sink
+	}
+}
+
+// 通道多路复用引擎，默认 EngineSelect
+// 附加大量通道（如每个连接一个通道）时，使用 EngineFunnel
+func GroupEngine(engine Engine) func(gc *groupconfig) {
+	return func(gc *groupconfig) {
+		gc.Engine = engine
+	}
+}
+
+// 启用公平调度，同一优先级的通道按差额轮询（DRR）处理
+// 	每轮每个通道可处理 quantum*weight 条数据，weight 由 AttachWeighted 指定，默认1
+// 	仅作用于 EngineSelect 引擎中最低优先级的通道，与 EngineFunnel 同时使用，
+// 	或附加了不同优先级的通道时，记录警告日志，其他通道不按权重调度
+func GroupFair(quantum int) func(gc *groupconfig) {
+	return func(gc *groupconfig) {
+		gc.Quantum = quantum
+	}
+}
+
+// 批量处理，同一通道的数据凑满 size 条，或等待 wait 后一起交给 IBatchProcessor
+// 	等待期间 group 协程不处理其他通道，wait 为0时只取通道中已就绪的数据
+// 	未实现 IBatchProcessor 的处理器仍逐条处理
+func GroupBatch(size int, wait time.Duration) func(gc *groupconfig) {
+	return func(gc *groupconfig) {
+		gc.BatchSize = size
+		gc.BatchWait = wait
+	}
+}
+
+// SendTo 每个目标通道最多缓冲 limit 条数据，<=0 不限制，默认1024
+// 	缓冲已满时按 policy 处理，默认 OverflowReject
+// 	SendTo 在 group 协程中调用，不能阻塞，OverflowBlock、OverflowBlockTimeout 同 OverflowReject
+func GroupSendBuffer(limit int, policy OverflowPolicy) func(gc *groupconfig) {
+	return func(gc *groupconfig) {
+		gc.SendLimit = limit
+		gc.SendPolicy = policy
+	}
+}
+
+// 收件箱已满时，Emit、Call、SlowCall 的处理策略，默认 OverflowBlock
+// 	timeout 为 OverflowBlockTimeout 的等待时间
+// 	TryEmit 不阻塞，OverflowBlock、OverflowBlockTimeout 时同 OverflowReject
+func GroupInbox(policy OverflowPolicy, timeout time.Duration) func(gc *groupconfig) {
+	return func(gc *groupconfig) {
+		gc.InboxPolicy = policy
+		gc.InboxTimeout = timeout
+	}
+}
+
+// 指标接收者，处理函数执行时间、收件箱等待时间和 panic 在 group 协程中报告给 sink
+// 	不指定时仍可通过 Stats 获取运行统计
+func GroupMetrics(sink MetricsSink) func(gc *groupconfig) {
+	return func(gc *groupconfig) {
+		gc.Metrics = sink
+	}
+}
+
+// 统计处理函数执行时间和收件箱等待时间的分布，通过 Stats 的 Handlers、QueueWait 读取
+// 	默认不统计，避免每条数据的额外开销
+func GroupHistograms() func(gc *groupconfig) {
+	return func(gc *groupconfig) {
+		gc.Histograms = true
+	}
+}
+
+// 处理一条数据超过 threshold 时，报告组名、正在执行的处理、数据类型和 group 协程的调用栈
+// 	repeat 为 true 时，每隔 threshold 重复报告，直到处理完成
+// 	由独立协程检查，无需处理函数配合
+func GroupWatchdog(threshold time.Duration, repeat bool) func(gc *groupconfig) {
+	return func(gc *groupconfig) {
+		gc.Watchdog = threshold
+		gc.WatchRepeat = repeat
+	}
+}
+
+// 处理超时报告，在看门狗协程中调用，不设置时记录日志
+func GroupWatchdogHandler(fn func(WatchdogReport)) func(gc *groupconfig) {
+	return func(gc *groupconfig) {
+		gc.OnSlow = fn
+	}
+}
+
+// 日志输出，支持 slog.Handler、*slog.Logger、zerolog.Logger、*zerolog.Logger 或 Logger
+// 	日志总是带有 group 字段
+// 	不指定时使用 zerolog 的全局 log.Logger
+func GroupLogger(logger interface{}) func(gc *groupconfig) {
+	l := toLogger(logger)
+	return func(gc *groupconfig) {
+		gc.Logger = l
+	}
+}
+
+// 追踪 Emit、Call、SlowCall，记录收件箱等待、处理函数执行和 SlowCall 执行的 span
+// 	父 span 来自 EmitContext、CallContext、SlowCallContext 的 ctx
+func GroupTracer(tracer Tracer) func(gc *groupconfig) {
+	return func(gc *groupconfig) {
+		gc.Tracer = tracer
+	}
+}
+
 // 构建通道聚合处理组
 func NewGroup(options ...GroupOption) *Group {
 	config := groupconfig{
-		ChannelLen: groupChanLen,
+		ChannelLen:  groupChanLen,
+		TimerTick:   defaultTimerTick,
+		Clock:       realClock{},
+		SendLimit:   defaultSendLimit,
+		InboxPolicy: OverflowBlock,
 	}
 	for _, option := range options {
 		option(&config)
 	}
+	if config.Clock == nil {
+		config.Clock = realClock{}
+	}
+	if config.ChannelLen < 0 {
+		config.ChannelLen = groupChanLen
+	}
 
 	g := &Group{
 		config:      config,
-		processChan: make(chan interface{}, groupChanLen),
+		processChan: make(chan interface{}, config.ChannelLen),
+		clock:       config.Clock,
 	}
 	if g.config.Name == "" {
 		number := atomic.AddInt64(&unnamegroup, 1)
 		g.config.Name = "Group" + strconv.FormatInt(number, 10)
 	}
 
-	if len(g.config.Handles) == 0 {
-		g.hub = newHub(groupChanLen, g.config.Recovery, g)
-	} else {
-		processors := append([]IDataProcessor{g}, g.config.Handles...)
-		g.hub = newHub(groupChanLen, g.config.Recovery, processors...)
+	processors := append([]IDataProcessor{g}, g.config.Handles...)
+	hc := hubconfig{
+		Name:        g.config.Name,
+		Engine:      g.config.Engine,
+		Quantum:     g.config.Quantum,
+		Now:         g.clock.Now,
+		ProducerLen: groupChanLen,
+		BatchSize:   g.config.BatchSize,
+		BatchWait:   g.config.BatchWait,
+		SendLimit:   g.config.SendLimit,
+		SendPolicy:  g.config.SendPolicy,
+		Recovery:    g.config.Recovery,
+		OnPanic:     g.onPanic,
+		Owner:       g,
+		Metrics:     g.config.Metrics,
+		Histograms:  g.config.Histograms,
+		Tracer:      g.config.Tracer,
+		Watchdog:    g.config.Watchdog,
+		WatchRepeat: g.config.WatchRepeat,
+		OnSlow:      g.config.OnSlow,
+		Logger:      g.config.Logger,
+	}
+	if g.config.DeadLetter != nil {
+		hc.OnDead = g.deadLetter
 	}
+	hc.OnExit = func() { groups.remove(g) }
+	g.hub = newHub(hc, processors...)
+
+	g.attachWait(func(cb func()) bool {
+		return g.hub.addControl(g.processChan, nil, cb)
+	})
+
+	// 时间轮的 ticker 通道，到期回调在 group 协程中执行
+	g.timers = newTimerWheel(g.config.TimerTick, g.clock)
+	g.attachWait(func(cb func()) bool {
+		return g.hub.addControl(g.timers.C(), func(tick interface{}) {
+			g.hub.mark("timer", tick)
+			start := time.Now()
+			g.timers.advance(tick.(time.Time))
+			g.hub.observe(HandlerKey{Kind: KindTimer, Name: "timer"}, start)
+		}, cb)
+	})
 
-	g.Attach(g.processChan)
+	// 登记为运行中的 Group，退出后移除
+	groups.add(g, nil)
+
+	if ctx := g.config.Context; ctx != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				g.stop(ctx.Err())
+			case <-g.hub.done:
+			}
+		}()
+	}
 	return g
 }
 
@@ -107,165 +338,428 @@ func (g *Group) Name() string {
 func (g *Group) OnData(data interface{}) interface{} {
 	switch x := data.(type) {
 	case asyncCall:
+		g.hub.observeWait(x.queued)
+		g.traceWait(x.ctx, SpanSlowCall, x.queued)
 		// 加入到hub，关注异步返回值
-		g.AttachCB(x.out, func() {
-			x.exec()
-		})
+		if x.out != nil {
+			g.hub.attach(x.out, producerInfo{level: priorityControl})
+		}
+		x.exec()
 	case asyncReturn:
+		g.hub.detach(x.out)
 		if x.callback != nil {
+			g.hub.mark("SlowCall", Return(x))
+			g.begin(x.ctx, SpanSlowCallback, time.Time{})
+			start := time.Now()
 			x.callback(Return(x))
+			g.hub.observe(HandlerKey{Kind: KindSlowCall, Name: "SlowCall"}, start)
+			g.end(nil)
 		}
-		close(x.out)
 	case asyncEventCall:
-		x.exec()
+		g.hub.observeWait(x.queued)
+		g.hub.mark("call:"+x.name, x.arg)
+		g.begin(x.ctx, "call:"+x.name, x.queued)
+		start := time.Now()
+		err := x.exec()
+		g.hub.observe(HandlerKey{Kind: KindCall, Name: x.name}, start)
+		g.end(err)
 	case eventCall:
+		g.hub.observeWait(x.queued)
+		g.hub.mark("event:"+x.name, x.arg)
+		g.begin(x.ctx, "event:"+x.name, x.queued)
+		start := time.Now()
 		x.exec(x.arg)
+		g.hub.observe(HandlerKey{Kind: KindEvent, Name: x.name}, start)
+		g.end(nil)
 	default:
 		return data
 	}
 
-	return data
+	return nil
 }
 
 // 发送事件，给 group 中的 handler 处理
 // 	返回值 registered 描述 event 是否注册了 handler
 func (g *Group) Emit(event string, arg interface{}) (registered bool) {
+	return g.EmitContext(context.Background(), event, arg)
+}
+
+// 发送事件，同 Emit，ctx 中的 span 作为处理函数执行的父 span
+// 	收件箱已满需要等待时，ctx 结束后放弃发送
+func (g *Group) EmitContext(ctx context.Context, event string, arg interface{}) (registered bool) {
 	h, exist := g.events.Load(event)
 	if !exist {
-		log.Trace().Str("event", event).Msg("not register event handler")
+		g.hub.log.trace("not register event handler", "event", event)
+		g.deadLetter(DeadUnregistered, "", nil, eventCall{name: event, arg: arg})
 		return false
 	}
 
-	g.processChan <- eventCall{exec: h.(func(arg interface{})), arg: arg}
+	x := eventCall{ctx: ctx, name: event, exec: h.(func(arg interface{})), arg: arg, queued: time.Now()}
+	if err := g.post(ctx, x, g.config.InboxPolicy); err != nil {
+		g.hub.log.trace("emit failed", "event", event, "error", err)
+		return false
+	}
 	return exist
 }
 
+// 发送事件，同 Emit，但不阻塞
+// 	收件箱已满时按 GroupInbox 指定的策略处理，OverflowBlock、OverflowBlockTimeout 时同 OverflowReject
+// 	返回 nil 表示已进入收件箱，否则返回 ErrNotRegistered、ErrOverflow 或 ErrGroupStopped
+func (g *Group) TryEmit(event string, arg interface{}) error {
+	h, exist := g.events.Load(event)
+	if !exist {
+		g.deadLetter(DeadUnregistered, "", nil, eventCall{name: event, arg: arg})
+		return fmt.Errorf("%w: event %s", ErrNotRegistered, event)
+	}
+
+	policy := g.config.InboxPolicy
+	if policy == OverflowBlock || policy == OverflowBlockTimeout {
+		policy = OverflowReject
+	}
+	x := eventCall{name: event, exec: h.(func(arg interface{})), arg: arg, queued: time.Now()}
+	return g.post(context.Background(), x, policy)
+}
+
 // 调用事件，跨协程调用group中的函数
 // 	event 事件名称
 // 	arg 附带参数，无参数传 nil
 // 	返回值 waitResult() Return 调用后将阻塞等待事件执行完毕，hotpot.Return 包含事件处理函数的返回值
 // 	返回值 registered 描述 event 是否注册了 handler
 func (g *Group) Call(event string, arg interface{}) (ret Return, registered bool) {
-	if isClosedChan(g.processChan) {
-		return
-	}
+	return g.CallContext(context.Background(), event, arg)
+}
 
+// 调用事件，同 Call，但在 ctx 取消或超时后立即返回
+// 	ctx 中的 span 作为处理函数执行的父 span
+// 	返回时 ctx 已结束，Return.Error 为 ctx.Err()，如 context.DeadlineExceeded
+// 	尚未执行的 handler 将被跳过，已在执行的 handler 返回值被丢弃
+// 	在 group 协程中调用会等待自身，此时 Return.Error 为 ErrInGroup，handler 不执行
+func (g *Group) CallContext(ctx context.Context, event string, arg interface{}) (ret Return, registered bool) {
 	h, exist := g.calls.Load(event)
 	if !exist {
-		log.Trace().Str("event", event).Msg("not register event handler")
+		g.hub.log.trace("not register event handler", "event", event)
+		g.deadLetter(DeadUnregistered, "", nil, asyncEventCall{name: event, arg: arg})
 		return
 	}
+	if g.hub.inHub() {
+		return Return{Error: ErrInGroup}, true
+	}
 
+	// out 容量为1，执行方写入返回值后关闭，调用方放弃等待也不会阻塞 group 协程
 	out := make(chan interface{}, 1)
-	fn := func() (ret Return) {
-		handler := func(arg Return) {
-			ret = arg
-		}
+	x := newEventAsyncCall(ctx, event, out, h.(func(arg interface{}) Return), arg)
+	if err := g.post(ctx, x, g.config.InboxPolicy); err != nil {
+		return Return{Error: err}, true
+	}
 
-		select {
-		case _, ok := <-out:
-			if ok {
-				close(out)
-			}
-			panic("handler cannot in Group goroutine")
-		default:
-			recvAsyncReturn(out, handler)()
+	select {
+	case v, ok := <-out:
+		if !ok {
+			return Return{Error: errors.New("return chan is closed before")}, true
 		}
-
-		return
+		return Return(v.(asyncReturn)), true
+	case <-ctx.Done():
+		return Return{Error: ctx.Err()}, true
+	case <-g.hub.done:
+		return Return{Error: ErrGroupStopped}, true
 	}
-
-	g.processChan <- newEventAsyncCall(out, h.(func(arg interface{}) Return), arg)
-
-	return fn(), true
 }
 
 // 绑定事件处理函数
 func (g *Group) ListenEvent(event string, handler func(arg interface{})) {
 	g.events.Store(event, handler) // 注册自定义事件
-	log.Trace().Str("event", event).Msg("register event handler")
+	g.types.Delete(typedKey{name: event})
+	g.hub.log.trace("register event handler", "event", event)
 }
 
 // 绑定调用处理函数
 func (g *Group) ListenCall(event string, handler func(arg interface{}) Return) {
 	g.calls.Store(event, handler) // 注册自定义事件
-	log.Trace().Str("call", event).Msg("register event call handler")
+	g.types.Delete(typedKey{call: true, name: event})
+	g.hub.log.trace("register event call handler", "call", event)
 }
 
 // 慢调用，用协程执行fn，并将结果送回到 group 协程
+// 	group 停止后不再接收，fn 和 callback 都不会执行
 func (g *Group) SlowCall(fn func(interface{}) Return, arg interface{}, callback func(Return)) {
-	if isClosedChan(g.processChan) {
+	g.SlowCallContext(context.Background(), func(_ context.Context, arg interface{}) Return {
+		return fn(arg)
+	}, arg, callback)
+}
+
+// 慢调用，同 SlowCall，ctx 中的 span 作为 fn 执行和 callback 的父 span
+// 	fn 收到带有其执行 span 的 ctx；收件箱已满需要等待时，ctx 结束后放弃调用
+func (g *Group) SlowCallContext(ctx context.Context, fn func(ctx context.Context, arg interface{}) Return, arg interface{}, callback func(Return)) {
+	g.slowCalls.add(1)
+	if callback == nil {
+		f := fn
+		fn = func(ctx context.Context, arg interface{}) Return {
+			defer g.slowCalls.add(-1)
+			return f(ctx, arg)
+		}
+	} else {
+		cb := callback
+		callback = func(ret Return) {
+			defer g.slowCalls.add(-1)
+			cb(ret)
+		}
+	}
+
+	x := newAsyncCall(ctx, fn, arg, callback, g.slowCallPanic, g.slowCallTrace())
+	if err := g.post(ctx, x, g.config.InboxPolicy); err != nil {
+		g.slowCalls.add(-1)
+		if err == ErrOverflow {
+			g.hub.log.warn("slow call dropped", "error", err)
+			return
+		}
+		g.hub.log.trace("slow call failed", "error", err)
+	}
+}
+
+// group 协程发生 panic，结束正在执行的处理函数的 span，再交给 GroupPanicHandler 或记录日志
+func (g *Group) onPanic(info PanicInfo) bool {
+	g.end(&PanicError{Value: info.Value, Stack: info.Stack})
+	if g.config.PanicHandler != nil {
+		return g.config.PanicHandler(info)
+	}
+	return g.hub.log.panic(info)
+}
+
+// SlowCall 的 fn 发生 panic，默认恢复，处理函数决定不恢复时停止 group
+func (g *Group) slowCallPanic(arg, r interface{}, stack []byte) {
+	g.deadLetter(DeadPanic, "SlowCall", nil, arg)
+
+	info := PanicInfo{
+		Group:     g.Name(),
+		Handler:   "SlowCall",
+		Data:      arg,
+		Value:     r,
+		Stack:     stack,
+		Remaining: -1,
+		Recover:   true,
+	}
+
+	onPanic := g.config.PanicHandler
+	if onPanic == nil {
+		onPanic = g.hub.log.panic
+	}
+	if !onPanic(info) {
+		g.stop(&PanicError{Value: r, Stack: stack})
+	}
+}
+
+// 报告死信，内部消息还原为事件、调用名称和参数
+func (g *Group) deadLetter(reason DeadReason, name string, producer, data interface{}) {
+	sink := g.config.DeadLetter
+	if sink == nil {
 		return
 	}
-	g.processChan <- newAsyncCall(fn, arg, callback)
+
+	if producer == g.processChan {
+		producer = nil
+	}
+
+	switch x := data.(type) {
+	case eventCall:
+		name, data = "event:"+x.name, x.arg
+	case asyncEventCall:
+		name, data = "call:"+x.name, x.arg
+	case asyncCall:
+		name, data = "SlowCall", x.arg
+	case asyncReturn:
+		name, data = "SlowCall", Return(x)
+	}
+
+	sink.Put(DeadLetter{
+		Reason:   reason,
+		Group:    g.Name(),
+		Name:     name,
+		Producer: producer,
+		Data:     data,
+		Time:     g.clock.Now(),
+	})
 }
 
 // 延时执行，超时后通过 group 协程调用 fn
-func (g *Group) AfterFunc(dur time.Duration, fn func()) {
-	g.SlowCall(func(_ interface{}) Return {
-		<-time.After(dur)
-		return Return{}
-	}, nil, func(_ Return) { fn() })
+// 	返回值 *Timer 可用于取消或重置
+func (g *Group) AfterFunc(dur time.Duration, fn func()) *Timer {
+	return g.timers.newTimer(dur, 0, func() bool {
+		fn()
+		return false
+	})
 }
 
-// 每隔dur执行fn，当fn返回false时终止
-func (g *Group) Tick(dur time.Duration, fn func() bool) {
-	g.SlowCall(func(_ interface{}) Return {
-		<-time.After(dur)
-		return Return{}
-	}, nil, g.tickCallback(dur, fn))
+// 每隔dur执行fn，当fn返回false或调用 Timer.Stop 时终止
+func (g *Group) Tick(dur time.Duration, fn func() bool) *Timer {
+	return g.timers.newTimer(dur, dur, fn)
 }
 
-func (g *Group) tickCallback(dur time.Duration, fn func() bool) func(Return) {
-	return func(Return) {
-		if !fn() {
-			return
+// 投递到收件箱，由 group 协程处理，收件箱已满时按 policy 处理
+// 	group 已停止时返回 ErrGroupStopped，并报告死信
+func (g *Group) post(ctx context.Context, x interface{}, policy OverflowPolicy) error {
+	if atomic.LoadInt32(&g.closing) == 1 {
+		g.deadLetter(DeadGroupStopped, "", nil, x)
+		return ErrGroupStopped
+	}
+
+	var timeout <-chan time.Time
+	switch policy {
+	case OverflowBlock:
+	case OverflowBlockTimeout:
+		timer := time.NewTimer(g.config.InboxTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	default:
+		for {
+			select {
+			case g.processChan <- x:
+				return g.posted()
+			default:
+			}
+
+			switch policy {
+			case OverflowDropOldest:
+				// 取出最早的数据，腾出空间后重试
+				select {
+				case old := <-g.processChan:
+					g.dropInbox(old)
+				default:
+				}
+				continue
+			case OverflowDropNewest:
+				g.deadLetter(DeadOverflow, "", nil, x)
+			}
+			return ErrOverflow
 		}
+	}
 
-		g.SlowCall(func(_ interface{}) Return {
-			<-time.After(dur)
-			return Return{}
-		}, nil, g.tickCallback(dur, fn))
+	select {
+	case g.processChan <- x:
+		return g.posted()
+	case <-timeout:
+		return ErrOverflow
+	case <-g.hub.done:
+		g.deadLetter(DeadGroupStopped, "", nil, x)
+		return ErrGroupStopped
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
-// 停止并释放资源
-func (g *Group) Stop() {
-	if !g.hub.IsWorking() {
-		return
+// 投递成功后检查 group 是否已停止
+// 	hub 协程先标记停止再取出收件箱中剩余的数据，之后投递的数据无人接收，
+// 	此时由投递方取出剩余数据作为死信报告，返回 ErrGroupStopped
+func (g *Group) posted() error {
+	if g.hub.IsWorking() {
+		return nil
 	}
+	for {
+		select {
+		case x := <-g.processChan:
+			g.deadLetter(DeadGroupStopped, "", nil, x)
+		default:
+			return ErrGroupStopped
+		}
+	}
+}
+
+// 丢弃收件箱中的数据，作为死信报告，等待中的调用方收到 ErrOverflow
+func (g *Group) dropInbox(x interface{}) {
+	switch v := x.(type) {
+	case asyncEventCall:
+		v.out <- asyncReturn(Return{Error: ErrOverflow})
+		close(v.out)
+	case asyncCall:
+		g.slowCalls.add(-1)
+	}
+	g.deadLetter(DeadOverflow, "", nil, x)
+}
 
-	g.hub.Stop()
-	close(g.processChan)
+// 立即停止，不处理已排队的数据，不执行停止钩子
+//
+// 需要处理完已排队的数据再停止时，使用 Shutdown
+func (g *Group) Stop() {
+	g.stop(ErrGroupStopped)
+}
+
+func (g *Group) stop(cause error) {
+	atomic.StoreInt32(&g.closing, 1)
+	g.timers.stop()
+	g.hub.stop(cause)
 }
 
 // 增加监听通道，同步等待，确保添加成功
-func (g *Group) Attach(producer chan interface{}) {
-	var wg sync.WaitGroup
-	wg.Add(1)
-	g.hub.Add(producer, func() {
-		wg.Done()
+// 	producer 可以是任意元素类型的可接收通道，数据经处理链处理
+// 	需要类型化处理函数时，使用 AttachTyped
+func (g *Group) Attach(producer interface{}) {
+	g.attachWait(func(cb func()) bool {
+		return g.hub.Add(producer, cb)
 	})
-	wg.Wait()
 }
 
 // 移除监听通道 producer，同步等待，确保移除成功
-func (g *Group) Detach(producer chan interface{}) {
-	var wg sync.WaitGroup
-	wg.Add(1)
-	g.hub.Remove(producer, func() {
-		wg.Done()
+// 	按通道识别，通道的双向形式和只读形式均可
+func (g *Group) Detach(producer interface{}) {
+	g.attachWait(func(cb func()) bool {
+		return g.hub.Remove(producer, cb)
+	})
+}
+
+// 同步等待 hub 执行完通道操作，group 停止时立即返回
+func (g *Group) attachWait(op func(cb func()) bool) {
+	done := make(chan struct{})
+	if !op(func() { close(done) }) {
+		return
+	}
+
+	select {
+	case <-done:
+	case <-g.hub.done:
+	}
+}
+
+// 增加指定优先级的监听通道，同步等待，确保添加成功
+// 	level 越大越优先，高优先级通道的数据总是先于低优先级处理
+// 	Emit、Call、定时器等内部通道优先级最高
+func (g *Group) AttachPriority(producer interface{}, level int) {
+	g.attachWait(func(cb func()) bool {
+		return g.hub.AddPriority(producer, level, cb)
 	})
-	wg.Wait()
+}
+
+// 增加带元数据的监听通道，同步等待，确保添加成功
+// 	实现 IEnvelopeProcessor 的处理器可从 Envelope.Meta 取得 meta
+func (g *Group) AttachWithMeta(producer interface{}, meta interface{}) {
+	g.attachWait(func(cb func()) bool {
+		return g.hub.AddWithMeta(producer, meta, cb)
+	})
+}
+
+// 增加指定公平调度权重的监听通道，同步等待，确保添加成功
+// 	启用 GroupFair 时，各通道的处理份额与权重成正比
+func (g *Group) AttachWeighted(producer interface{}, weight int) {
+	g.attachWait(func(cb func()) bool {
+		return g.hub.AddWeighted(producer, weight, cb)
+	})
+}
+
+// 各监听通道的接收统计，不含 Emit、Call、定时器等内部通道
+// 	group 已停止时返回 nil
+func (g *Group) ProducerStats() []ProducerStat {
+	var stats []ProducerStat
+	g.runInGroup(context.Background(), func() {
+		stats = g.hub.producerStats()
+	})
+	return stats
 }
 
 // 增加监听通道
-func (g *Group) AttachCB(producer chan interface{}, cb func()) {
+func (g *Group) AttachCB(producer interface{}, cb func()) {
 	g.hub.Add(producer, cb)
 }
 
 // 移除监听通道 producer
-func (g *Group) DetachCB(producer chan interface{}, cb func()) {
+func (g *Group) DetachCB(producer interface{}, cb func()) {
 	g.hub.Remove(producer, cb)
 }
 
@@ -274,6 +768,20 @@ func (g *Group) IsWorking() bool {
 	return g.hub.IsWorking()
 }
 
+// group 协程退出后关闭
+func (g *Group) Done() <-chan struct{} {
+	return g.hub.Done()
+}
+
+// 退出原因，group 协程未退出时返回 nil
+// 	ErrGroupStopped 调用 Stop 或 Shutdown 正常停止
+// 	*PanicError panic 超出恢复次数，包含 panic 值和调用栈
+// 	context.Canceled、context.DeadlineExceeded GroupContext 指定的 ctx 结束
+// 	*ShutdownError 优雅停止超时或失败
+func (g *Group) Err() error {
+	return g.hub.Err()
+}
+
 // 数据处理链
 func (g *Group) Processors() *Queue {
 	return g.hub.processors
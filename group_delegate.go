@@ -1,10 +1,6 @@
 package hub
 
-import (
-	"sync/atomic"
-
-	"github.com/rs/zerolog/log"
-)
+import "sync/atomic"
 
 // 工作组，有委托能力
 //
@@ -23,12 +19,13 @@ func newGroupDelegate(options ...GroupOption) *groupDelegate {
 	}
 
 	gd.delegated.Store(false)
+	groups.add(gd.Group, gd)
 
 	return gd
 }
 
 // 委托其他工作组处理生产数据
-func (gd *groupDelegate) DelegateChan(producer chan interface{}, g *Group) {
+func (gd *groupDelegate) DelegateChan(producer interface{}, g *Group) {
 	if gd.IsDelegated() {
 		// 已经建立其他委托关系，必须停止才能再次委托
 		panic("already delegate to some group, must stop it first")
@@ -36,22 +33,22 @@ func (gd *groupDelegate) DelegateChan(producer chan interface{}, g *Group) {
 
 	gd.DetachCB(producer, func() {
 		gd.delegated.Store(true)
-		log.Trace().Bool("delegated", gd.IsDelegated()).Msg("委托生效")
+		gd.hub.log.trace("委托生效", "delegated", gd.IsDelegated())
 		g.Attach(producer)
 	})
 }
 
 // 中止委托关系，并自己处理工作
-func (gd *groupDelegate) SelfSupport(producer chan interface{}, g *Group) {
+func (gd *groupDelegate) SelfSupport(producer interface{}, g *Group) {
 	if !gd.IsDelegated() {
-		log.Trace().Bool("delegated", gd.IsDelegated()).Msg("没有建立委托")
+		gd.hub.log.trace("没有建立委托", "delegated", gd.IsDelegated())
 		return // 没有建立委托
 	}
 
 	fn := func() {
 		// 标记未委托
 		gd.delegated.Store(false)
-		log.Trace().Bool("delegated", gd.IsDelegated()).Msg("标记未委托")
+		gd.hub.log.trace("标记未委托", "delegated", gd.IsDelegated())
 	}
 	// 重新监听生产通道
 	g.DetachCB(producer, func() {
@@ -66,7 +63,7 @@ func (gd *groupDelegate) StopDelegate() bool {
 	}
 
 	// 标记未委托
-	log.Trace().Bool("delegated", gd.IsDelegated()).Msg("中止委托关系")
+	gd.hub.log.trace("中止委托关系", "delegated", gd.IsDelegated())
 	gd.delegated.Store(false)
 	return true
 }
@@ -0,0 +1,447 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_CallContext(t *testing.T) {
+	g := NewGroup()
+	defer g.Stop()
+
+	release := make(chan struct{})
+	g.ListenCall("卡住", func(arg interface{}) Return {
+		<-release
+		return Return{Value: arg}
+	})
+	g.ListenCall("回显", func(arg interface{}) Return {
+		return Return{Value: arg}
+	})
+
+	t.Run("超时返回", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		ret, registered := g.CallContext(ctx, "卡住", 1)
+		if !registered {
+			t.Fatal("call not registered")
+		}
+		if ret.Error != context.DeadlineExceeded {
+			t.Fatalf("want DeadlineExceeded, got %v", ret.Error)
+		}
+		close(release)
+	})
+
+	t.Run("取消后跳过", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		ret, _ := g.CallContext(ctx, "回显", 2)
+		if ret.Error != context.Canceled {
+			t.Fatalf("want Canceled, got %v", ret.Error)
+		}
+	})
+
+	t.Run("正常返回", func(t *testing.T) {
+		ret, _ := g.CallContext(context.Background(), "回显", 3)
+		if ret.Error != nil || ret.Value != 3 {
+			t.Fatalf("unexpected return %+v", ret)
+		}
+	})
+
+	t.Run("在group协程中调用", func(t *testing.T) {
+		g.ListenCall("嵌套", func(arg interface{}) Return {
+			ret, registered := g.CallContext(context.Background(), "回显", arg)
+			if !registered {
+				t.Error("call not registered")
+			}
+			return ret
+		})
+		ret, _ := g.Call("嵌套", 4)
+		if ret.Error != ErrInGroup {
+			t.Fatalf("want ErrInGroup, got %v", ret.Error)
+		}
+	})
+}
+
+func Test_Shutdown(t *testing.T) {
+	var steps []ShutdownStep
+	rejected := make(chan struct{})
+	g := NewGroup(GroupShutdownReport(func(step ShutdownStep, err error) {
+		if err != nil {
+			t.Errorf("step %v: %v", step, err)
+		}
+		steps = append(steps, step)
+		if step == ShutdownReject {
+			close(rejected)
+		}
+	}))
+
+	// 阻塞 group 协程，使后续事件排队
+	block := make(chan struct{})
+	g.ListenEvent("block", func(interface{}) { <-block })
+	var handled int
+	g.ListenEvent("count", func(interface{}) { handled++ })
+	g.ListenCall("echo", func(arg interface{}) Return { return Return{Value: arg} })
+
+	g.Emit("block", nil)
+	for i := 0; i < 5; i++ {
+		g.Emit("count", i)
+	}
+
+	slowDone := make(chan struct{})
+	var slowCallback bool
+	g.SlowCall(func(interface{}) Return {
+		<-slowDone
+		return Return{}
+	}, nil, func(Return) { slowCallback = true })
+
+	var hooked bool
+	g.OnStop(func() { hooked = handled == 5 && slowCallback })
+
+	result := make(chan error)
+	go func() { result <- g.Shutdown(context.Background()) }()
+
+	<-rejected
+	if g.Emit("count", 6) {
+		t.Fatal("emit accepted after shutdown")
+	}
+	if ret, _ := g.Call("echo", 1); ret.Error != ErrGroupStopped {
+		t.Fatalf("want ErrGroupStopped, got %v", ret.Error)
+	}
+
+	close(block)
+	close(slowDone)
+	if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+	if !hooked {
+		t.Fatalf("hook ran too early: handled %d, slow callback %v", handled, slowCallback)
+	}
+	if g.IsWorking() {
+		t.Fatal("still working after shutdown")
+	}
+	if len(steps) != 5 {
+		t.Fatalf("steps %v", steps)
+	}
+
+	t.Run("超时", func(t *testing.T) {
+		g := NewGroup()
+		g.SlowCall(func(interface{}) Return {
+			select {}
+		}, nil, func(Return) {})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		err := g.Shutdown(ctx)
+		var se *ShutdownError
+		if !errors.As(err, &se) || se.Step != ShutdownWait || !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("unexpected %v", err)
+		}
+	})
+
+	t.Run("在group协程中调用", func(t *testing.T) {
+		g := NewGroup(GroupRecovery(1))
+		g.ListenCall("shutdown", func(interface{}) Return {
+			return Return{Error: g.Shutdown(context.Background())}
+		})
+		g.ListenEvent("crash", func(interface{}) { panic("crash") })
+
+		if ret, _ := g.Call("shutdown", nil); ret.Error != ErrInGroup {
+			t.Fatalf("want ErrInGroup, got %v", ret.Error)
+		}
+		// panic 恢复后 group 协程已更换
+		g.Emit("crash", nil)
+		if ret, _ := g.Call("shutdown", nil); ret.Error != ErrInGroup {
+			t.Fatalf("want ErrInGroup, got %v", ret.Error)
+		}
+		if !g.IsWorking() {
+			t.Fatal("stopped")
+		}
+		if err := g.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+type tPanicProcessor struct{}
+
+func (tPanicProcessor) Name() string {
+	return "tPanicProcessor"
+}
+func (tPanicProcessor) OnData(data interface{}) interface{} {
+	panic(data)
+}
+
+func Test_Done(t *testing.T) {
+	t.Run("正常停止", func(t *testing.T) {
+		g := NewGroup()
+		if g.Err() != nil {
+			t.Fatal("Err before done")
+		}
+		g.Stop()
+		<-g.Done()
+		if g.Err() != ErrGroupStopped {
+			t.Fatalf("want ErrGroupStopped, got %v", g.Err())
+		}
+	})
+
+	t.Run("panic超出恢复次数", func(t *testing.T) {
+		g := NewGroup(GroupRecovery(1), GroupHandles(&tPanicProcessor{}))
+		ch := make(chan interface{})
+		g.Attach(ch)
+		ch <- 1
+		ch <- 2
+
+		<-g.Done()
+		var pe *PanicError
+		if !errors.As(g.Err(), &pe) || pe.Value != 2 || len(pe.Stack) == 0 {
+			t.Fatalf("unexpected %v", g.Err())
+		}
+		if g.IsWorking() {
+			t.Fatal("still working after panic")
+		}
+	})
+
+	t.Run("ctx结束", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		g := NewGroup(GroupContext(ctx))
+		cancel()
+		<-g.Done()
+		if g.Err() != context.Canceled {
+			t.Fatalf("want Canceled, got %v", g.Err())
+		}
+	})
+}
+
+func Test_PanicHandler(t *testing.T) {
+	t.Run("事件", func(t *testing.T) {
+		infos := make(chan PanicInfo, 2)
+		g := NewGroup(GroupName("panic"), GroupRecovery(1), GroupPanicHandler(func(info PanicInfo) bool {
+			infos <- info
+			return info.Recover
+		}))
+		g.ListenEvent("boom", func(arg interface{}) {
+			panic("boom")
+		})
+
+		g.Emit("boom", 1)
+		info := <-infos
+		if info.Group != "panic" || info.Handler != "event:boom" || info.Data != 1 ||
+			info.Value != "boom" || info.Remaining != 0 || !info.Recover || len(info.Stack) == 0 {
+			t.Fatalf("unexpected %+v", info)
+		}
+
+		g.Emit("boom", 2)
+		info = <-infos
+		if info.Recover {
+			t.Fatal("recover beyond budget")
+		}
+		<-g.Done()
+	})
+
+	t.Run("处理器", func(t *testing.T) {
+		infos := make(chan PanicInfo, 1)
+		g := NewGroup(GroupHandles(&tPanicProcessor{}), GroupPanicHandler(func(info PanicInfo) bool {
+			infos <- info
+			return true // 超出恢复次数仍然恢复
+		}))
+		ch := make(chan interface{})
+		g.Attach(ch)
+		ch <- 1
+		if info := <-infos; info.Handler != "tPanicProcessor" || info.Data != 1 || info.Recover {
+			t.Fatalf("unexpected %+v", info)
+		}
+		if !g.IsWorking() {
+			t.Fatal("not recovered")
+		}
+		g.Stop()
+	})
+
+	t.Run("SlowCall", func(t *testing.T) {
+		infos := make(chan PanicInfo, 1)
+		g := NewGroup(GroupPanicHandler(func(info PanicInfo) bool {
+			infos <- info
+			return true
+		}))
+		defer g.Stop()
+
+		ret := make(chan Return, 1)
+		g.SlowCall(func(interface{}) Return {
+			panic("slow")
+		}, nil, func(r Return) {
+			ret <- r
+		})
+
+		if info := <-infos; info.Handler != "SlowCall" || info.Value != "slow" {
+			t.Fatalf("unexpected %+v", info)
+		}
+		var pe *PanicError
+		if r := <-ret; !errors.As(r.Error, &pe) || pe.Value != "slow" {
+			t.Fatalf("unexpected %v", r.Error)
+		}
+		if err := g.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func Test_Inbox(t *testing.T) {
+	// 收件箱长度2，group 协程卡在 block 事件中，收件箱排队 1、2，再投递 3
+	setup := func(t *testing.T, policy OverflowPolicy) (*Group, *DeadLetterRing, func() []interface{}) {
+		ring := NewDeadLetterRing(8)
+		g := NewGroup(GroupChannelLen(2), GroupInbox(policy, 20*time.Millisecond), GroupDeadLetter(ring))
+		t.Cleanup(g.Stop)
+
+		started, release := make(chan struct{}), make(chan struct{})
+		g.ListenEvent("block", func(interface{}) {
+			close(started)
+			<-release
+		})
+		var got []interface{}
+		g.ListenEvent("e", func(arg interface{}) {
+			got = append(got, arg)
+		})
+
+		g.Emit("block", nil)
+		<-started
+		for i := 1; i <= 2; i++ {
+			if err := g.TryEmit("e", i); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		wait := func() []interface{} {
+			close(release)
+			g.Shutdown(context.Background())
+			return got
+		}
+		return g, ring, wait
+	}
+
+	t.Run("拒绝", func(t *testing.T) {
+		g, ring, wait := setup(t, OverflowReject)
+		if err := g.TryEmit("e", 3); !errors.Is(err, ErrOverflow) {
+			t.Fatalf("want ErrOverflow, got %v", err)
+		}
+		if g.Emit("e", 3) {
+			t.Fatal("emit accepted")
+		}
+		if got := wait(); len(got) != 2 || len(ring.Letters()) != 0 {
+			t.Fatalf("got %v letters %+v", got, ring.Letters())
+		}
+	})
+
+	t.Run("丢弃新数据", func(t *testing.T) {
+		g, ring, wait := setup(t, OverflowDropNewest)
+		if err := g.TryEmit("e", 3); !errors.Is(err, ErrOverflow) {
+			t.Fatalf("want ErrOverflow, got %v", err)
+		}
+		letters := ring.Letters()
+		if got := wait(); len(got) != 2 || len(letters) != 1 || letters[0].Reason != DeadOverflow ||
+			letters[0].Name != "event:e" || letters[0].Data != 3 {
+			t.Fatalf("got %v letters %+v", got, letters)
+		}
+	})
+
+	t.Run("丢弃最早的数据", func(t *testing.T) {
+		g, ring, wait := setup(t, OverflowDropOldest)
+		// 被丢弃的调用，调用方收到 ErrOverflow
+		g.ListenCall("c", func(interface{}) Return { return Return{} })
+		ret := make(chan Return, 1)
+		go func() {
+			r, _ := g.Call("c", nil)
+			ret <- r
+		}()
+		for len(ring.Letters()) != 1 || len(g.processChan) != 2 {
+			time.Sleep(time.Millisecond)
+		}
+
+		for i := 3; i <= 4; i++ {
+			if err := g.TryEmit("e", i); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if r := <-ret; !errors.Is(r.Error, ErrOverflow) {
+			t.Fatalf("want ErrOverflow, got %v", r.Error)
+		}
+
+		letters := ring.Letters()
+		if got := wait(); len(got) != 2 || got[0] != 3 || got[1] != 4 || len(letters) != 3 ||
+			letters[0].Data != 1 || letters[1].Data != 2 || letters[2].Name != "call:c" {
+			t.Fatalf("got %v letters %+v", got, letters)
+		}
+	})
+
+	t.Run("停止时投递的数据不丢失", func(t *testing.T) {
+		for _, policy := range []OverflowPolicy{OverflowBlock, OverflowReject} {
+			ring := NewDeadLetterRing(1 << 16)
+			g := NewGroup(GroupInbox(policy, 0), GroupDeadLetter(ring))
+			var (
+				handled sync.Map
+				once    sync.Once
+			)
+			busy := make(chan struct{})
+			g.ListenEvent("e", func(arg interface{}) {
+				handled.Store(arg, true)
+				once.Do(func() { close(busy) })
+			})
+
+			var (
+				wg       sync.WaitGroup
+				mu       sync.Mutex
+				accepted []int
+			)
+			for w := 0; w < 4; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := w; ; i += 4 {
+						err := g.TryEmit("e", i)
+						if errors.Is(err, ErrGroupStopped) {
+							return
+						}
+						if err == nil {
+							mu.Lock()
+							accepted = append(accepted, i)
+							mu.Unlock()
+						}
+					}
+				}(w)
+			}
+			<-busy
+			g.Stop()
+			wg.Wait()
+
+			dead := make(map[interface{}]bool)
+			for _, l := range ring.Letters() {
+				dead[l.Data] = true
+			}
+			for _, i := range accepted {
+				if _, ok := handled.Load(i); !ok && !dead[i] {
+					t.Fatalf("%v: %d accepted but neither handled nor dead-lettered", policy, i)
+				}
+			}
+		}
+	})
+
+	t.Run("阻塞超时", func(t *testing.T) {
+		g, _, wait := setup(t, OverflowBlockTimeout)
+		start := time.Now()
+		if g.Emit("e", 3) {
+			t.Fatal("emit accepted")
+		}
+		if time.Since(start) < 20*time.Millisecond {
+			t.Fatal("not blocked")
+		}
+		if err := g.TryEmit("e", 3); !errors.Is(err, ErrOverflow) {
+			t.Fatalf("want ErrOverflow, got %v", err)
+		}
+		if got := wait(); len(got) != 2 {
+			t.Fatalf("got %v", got)
+		}
+	})
+}
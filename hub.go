@@ -1,156 +1,792 @@
 package hub
 
 import (
+	"fmt"
+	"math"
 	"reflect"
-	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
 	"sync/atomic"
+	"time"
+)
+
+// 通道多路复用引擎
+type Engine int
 
-	"github.com/rs/zerolog/log"
+const (
+	// reflect.Select 监听所有通道，每次接收 O(n)，最多65536个通道，适合少量通道
+	EngineSelect Engine = iota
+	// 每个通道由一个转发协程接收，汇聚到 hub 协程，适合大量通道
+	EngineFunnel
 )
 
-const stackBufferSize = 2048
+// 通道优先级，数值越大越优先，高优先级通道的数据总是先于低优先级处理
+const (
+	PriorityLow    = -10
+	PriorityNormal = 0 // Attach 默认优先级
+	PriorityHigh   = 10
+
+	priorityControl = math.MaxInt32 // 内部控制通道，如通道操作、Emit、Call、定时器
+)
+
+// 固定监听的通道序号
+const (
+	controlCase   = 0 // producer 操作通道
+	quitCase      = 1 // 停止信号
+	firstProducer = 2 // 之后是生产者通道
+)
 
 // Hub 监听多个通道数据
 type Hub struct {
+	name       string
 	producer   chan producerOp
 	processors *Queue
+	onPanic    func(PanicInfo) bool
+	onDead     func(reason DeadReason, name string, producer, data interface{})
+	engine     Engine
+	quantum    int // 公平调度每轮每单位权重的额度，0 表示不启用
+	batchSize  int // 批量处理时每批最多的数据数量，<=1 不批量处理
+	batchWait  time.Duration
+	now        func() time.Time
+	owner      IDataProcessor // 自行记录处理时间的处理器，如 Group
+	metrics    *hubMetrics
+	tracer     Tracer    // 记录处理器执行的 span，nil 表示不启用
+	watchdog   *watchdog // 处理超时报告，nil 表示不启用
+	log        logger
+	onExit     func()
+	recovery   int64 // 剩余恢复次数，-1 表示总是恢复，可在任意协程读取
+	gid        int64 // hub 协程的 goroutine id，panic 恢复后会变化，可在任意协程读取
+
+	ps       producerSet   // select 引擎监听的通道，只在 hub 协程中访问
+	fn       funnel        // funnel 引擎的转发协程，只在 hub 协程中访问
+	out      outbox        // SendTo 的发送缓冲，只在 hub 协程中访问
+	batch    []interface{} // 批量处理的缓冲，只在 hub 协程中访问
+	running  string        // 正在执行的处理器、事件或调用名称，只在 hub 协程中访问
+	data     interface{}   // 正在处理的数据，只在 hub 协程中访问
+	source   interface{}   // 正在处理的数据来源通道，只在 hub 协程中访问
+	warned   bool          // 已警告 GroupFair 不作用于部分通道，只在 hub 协程中访问
+	quit     chan struct{}
+	quitOnce sync.Once
+	done     chan struct{} // hub 协程退出后关闭
+	working  atomic.Value
 
-	keep    atomic.Value
-	working atomic.Value
+	mu    sync.Mutex
+	cause error // 停止原因
+	err   error // 退出原因，done 关闭后有效
+}
+
+// hub 协程因 panic 退出，且超出恢复次数
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("hub: panic: %v", e.Value)
+}
+
+// panic 报告
+type PanicInfo struct {
+	Group     string      // group 名称
+	Handler   string      // 发生 panic 时执行的处理器、事件或调用，如 event:login、call:query
+	Data      interface{} // 正在处理的数据
+	Value     interface{} // panic 值
+	Stack     []byte      // 完整调用栈
+	Remaining int         // 剩余恢复次数，-1 表示总是恢复
+	Recover   bool        // 按恢复次数决定是否恢复
 }
 
 type producerOp struct {
-	producer chan interface{}
+	producer interface{} // 任意可接收的通道
+	info     producerInfo
 	op       producerOpType
 	cb       func()
 }
 
+// 监听的通道集合，cases 与 infos 一一对应
+type producerSet struct {
+	cases  []reflect.SelectCase
+	infos  []producerInfo
+	tiers  []selectTier // 除最低优先级外，按优先级从高到低分组
+	lowest int          // 最低优先级
+	dirty  bool         // 通道变化后需重建 tiers
+
+	cursor int // 公平调度当前轮到的通道序号
+}
+
+type producerInfo struct {
+	handler  func(interface{}) // 非 nil 时直接处理该通道数据，不经过处理链，如定时器、AttachTyped
+	level    int               // 优先级
+	weight   int               // 公平调度权重
+	deficit  int               // 公平调度本轮剩余额度
+	received uint64            // 已接收的数据数量
+	meta     interface{}       // AttachWithMeta 指定的元数据
+}
+
+// 由 handler 直接处理的内部控制通道，如定时器，剩余数据不作为死信报告
+func (info *producerInfo) internal() bool {
+	return info.handler != nil && info.level == priorityControl
+}
+
+// 同一优先级的通道，末尾为 default，用于非阻塞检查
+type selectTier struct {
+	cases []reflect.SelectCase
+	index []int // cases 在 producerSet.cases 中的序号
+}
+
+func (ps *producerSet) add(c reflect.SelectCase, info producerInfo) {
+	ps.cases = append(ps.cases, c)
+	ps.infos = append(ps.infos, info)
+	ps.dirty = true
+}
+
+func (ps *producerSet) remove(i int) {
+	ps.cases = append(ps.cases[:i], ps.cases[i+1:]...)
+	ps.infos = append(ps.infos[:i], ps.infos[i+1:]...)
+	ps.dirty = true
+	if i <= ps.cursor {
+		// 移除当前或之前的通道，从下一个通道继续
+		ps.cursor--
+	}
+}
+
+// 按优先级从高到低非阻塞检查，最低优先级不检查，没有就绪的通道时返回 -1
+func (ps *producerSet) poll() (int, reflect.Value, bool) {
+	if ps.dirty {
+		ps.build()
+	}
+
+	for _, t := range ps.tiers {
+		chosen, recv, ok := reflect.Select(t.cases)
+		if chosen < len(t.cases)-1 {
+			return t.index[chosen], recv, ok
+		}
+	}
+	return -1, reflect.Value{}, false
+}
+
+func (ps *producerSet) build() {
+	ps.dirty = false
+	ps.tiers = ps.tiers[:0]
+
+	levels := make([]int, len(ps.infos))
+	for i, info := range ps.infos {
+		levels[i] = info.level
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(levels)))
+	n := 0
+	for _, level := range levels {
+		if n == 0 || levels[n-1] != level {
+			levels[n] = level
+			n++
+		}
+	}
+
+	// 最低优先级在阻塞等待时处理
+	ps.lowest = levels[n-1]
+	for _, level := range levels[:n-1] {
+		var t selectTier
+		for i, info := range ps.infos {
+			if info.level == level {
+				t.cases = append(t.cases, ps.cases[i])
+				t.index = append(t.index, i)
+			}
+		}
+		t.cases = append(t.cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+		ps.tiers = append(ps.tiers, t)
+	}
+}
+
 type producerOpType int
 
 const (
 	addProducer    producerOpType = 1
 	removeProducer producerOpType = 2
+	execOp         producerOpType = 3 // 在 hub 协程中执行 cb
 )
 
+type hubconfig struct {
+	Name        string
+	Engine      Engine
+	Quantum     int // 公平调度每轮每单位权重的额度，0 表示不启用
+	BatchSize   int // 批量处理时每批最多的数据数量，<=1 不批量处理
+	BatchWait   time.Duration
+	Now         func() time.Time
+	ProducerLen int
+	SendLimit   int // SendTo 每个目标通道最多缓冲的数据数量，<=0 不限制
+	SendPolicy  OverflowPolicy
+	Recovery    int                  // -1 总是恢复； 0 不恢复； >0 恢复次数
+	OnPanic     func(PanicInfo) bool // 决定是否恢复，nil 时记录日志并按 Recovery 恢复
+	Owner       IDataProcessor       // 自行记录处理时间的处理器
+	Metrics     MetricsSink
+	Histograms  bool                 // 统计耗时分布，供 Stats 读取
+	Tracer      Tracer               // 记录处理器执行的 span，nil 表示不启用
+	Watchdog    time.Duration        // 处理超过该时间时报告，0 表示不启用
+	WatchRepeat bool                 // 超时后每隔 Watchdog 重复报告
+	OnSlow      func(WatchdogReport) // nil 时记录日志
+	Logger      Logger               // nil 时使用 zerolog 的全局 log.Logger
+	OnExit      func()               // hub 协程退出，Done 关闭前调用
+	// 未处理的数据，panic 时正在处理的数据，以及停止时通道中剩余的数据
+	// name 为 panic 时正在执行的处理
+	OnDead func(reason DeadReason, name string, producer, data interface{})
+}
+
 // NewHub 构建Hub
-//
-// @param recovery -1 总是恢复； 0 不恢复； >0 恢复次数
-func newHub(producerLen int, recovery int, processors ...IDataProcessor) *Hub {
+func newHub(config hubconfig, processors ...IDataProcessor) *Hub {
+	if config.Logger == nil {
+		config.Logger = defaultLogger
+	}
+	l := logger{out: config.Logger, group: config.Name}
+	if config.OnPanic == nil {
+		config.OnPanic = l.panic
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
 	hub := &Hub{
+		name:       config.Name,
+		onPanic:    config.OnPanic,
+		onDead:     config.OnDead,
+		engine:     config.Engine,
+		quantum:    config.Quantum,
+		batchSize:  config.BatchSize,
+		batchWait:  config.BatchWait,
+		now:        config.Now,
+		owner:      config.Owner,
+		metrics:    newHubMetrics(config.Metrics, config.Histograms),
+		tracer:     config.Tracer,
+		log:        l,
+		onExit:     config.OnExit,
+		out:        outbox{limit: config.SendLimit, policy: config.SendPolicy},
 		processors: newQueue(processors),
-		producer:   make(chan producerOp, producerLen),
+		producer:   make(chan producerOp, config.ProducerLen),
+		quit:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	hub.ps.add(reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(hub.producer),
+	}, producerInfo{level: priorityControl})
+	hub.ps.add(reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(hub.quit),
+	}, producerInfo{level: priorityControl})
+	hub.fn.init()
+	if config.Quantum > 0 && config.Engine == EngineFunnel {
+		l.warn("GroupFair has no effect with EngineFunnel")
+	}
+	if config.Watchdog > 0 {
+		if config.OnSlow == nil {
+			config.OnSlow = l.slow
+		}
+		hub.watchdog = &watchdog{
+			group:     config.Name,
+			threshold: config.Watchdog,
+			repeat:    config.WatchRepeat,
+			report:    config.OnSlow,
+		}
+		go hub.watchdog.run(hub.done)
 	}
 
-	hub.working.Store(false)
-	go hub.process(recovery)
+	hub.working.Store(true)
+	go hub.process(config.Recovery)
 	return hub
 }
 
-// 添加生产者通道
-func (h *Hub) Add(producer chan interface{}, cb func()) {
-	h.producer <- producerOp{producer, addProducer, cb}
+// 添加生产者通道，producer 可以是任意元素类型的可接收通道，如 chan []byte、<-chan Event
+// 	返回 false 表示 hub 已停止，cb 不会被调用
+func (h *Hub) Add(producer interface{}, cb func()) bool {
+	return h.AddPriority(producer, PriorityNormal, cb)
+}
+
+// 添加指定优先级的生产者通道，level 越大越优先
+// 	返回 false 表示 hub 已停止，cb 不会被调用
+func (h *Hub) AddPriority(producer interface{}, level int, cb func()) bool {
+	checkProducer(producer)
+	return h.send(producerOp{producer: producer, info: producerInfo{level: level}, op: addProducer, cb: cb})
+}
+
+// 添加指定公平调度权重的生产者通道，启用公平调度时，按权重分配处理份额
+// 	返回 false 表示 hub 已停止，cb 不会被调用
+func (h *Hub) AddWeighted(producer interface{}, weight int, cb func()) bool {
+	checkProducer(producer)
+	return h.send(producerOp{producer: producer, info: producerInfo{weight: weight}, op: addProducer, cb: cb})
+}
+
+// 添加带元数据的生产者通道，元数据随数据交给 IEnvelopeProcessor
+// 	返回 false 表示 hub 已停止，cb 不会被调用
+func (h *Hub) AddWithMeta(producer interface{}, meta interface{}, cb func()) bool {
+	checkProducer(producer)
+	return h.send(producerOp{producer: producer, info: producerInfo{meta: meta}, op: addProducer, cb: cb})
+}
+
+// 添加最高优先级的内部控制通道，handler 非 nil 时数据由 handler 直接处理
+func (h *Hub) addControl(producer interface{}, handler func(interface{}), cb func()) bool {
+	return h.send(producerOp{producer: producer, info: producerInfo{handler: handler, level: priorityControl}, op: addProducer, cb: cb})
 }
 
 // 移除生产者通道
-func (h *Hub) Remove(producer chan interface{}, cb func()) {
-	h.producer <- producerOp{producer, removeProducer, cb}
+// 	返回 false 表示 hub 已停止，cb 不会被调用
+func (h *Hub) Remove(producer interface{}, cb func()) bool {
+	return h.send(producerOp{producer: producer, op: removeProducer, cb: cb})
 }
 
-func (h *Hub) process(recovery int) {
-	h.working.Store(true)
-
-	var cases []reflect.SelectCase
-	backup := h.keep.Load()
-	if backup == nil {
-		cases = []reflect.SelectCase{
-			{
-				Dir:  reflect.SelectRecv,
-				Chan: reflect.ValueOf(h.producer),
-			},
-		}
-		h.keep.Store(cases)
-	} else {
-		// 存在备份时，直接恢复
-		cases = backup.([]reflect.SelectCase)
+// 检查 producer 是可接收的通道，否则 panic
+func checkProducer(producer interface{}) {
+	t := reflect.TypeOf(producer)
+	if t == nil || t.Kind() != reflect.Chan || t.ChanDir()&reflect.RecvDir == 0 {
+		panic(fmt.Sprintf("hub: producer must be a receivable channel, got %T", producer))
 	}
+}
 
-	defer func() {
-		if r := recover(); r != nil {
-			if stackBufferSize > 0 {
-				buf := make([]byte, stackBufferSize)
-				l := runtime.Stack(buf, false)
-				log.Printf("%v: %s", r, buf[:l])
-			} else {
-				log.Printf("%v", r)
+// a、b 是否为同一通道，同一通道的双向和只读形式视为相同
+func sameProducer(a, b interface{}) bool {
+	if a == b {
+		return true
+	}
+
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	return va.Kind() == reflect.Chan && vb.Kind() == reflect.Chan &&
+		va.Pointer() != 0 && va.Pointer() == vb.Pointer()
+}
+
+// 在 hub 协程中执行 fn
+func (h *Hub) exec(fn func()) bool {
+	return h.send(producerOp{op: execOp, cb: fn})
+}
+
+func (h *Hub) send(op producerOp) bool {
+	select {
+	case <-h.quit:
+		return false
+	default:
+	}
+
+	select {
+	case h.producer <- op:
+		return true
+	case <-h.quit:
+		return false
+	case <-h.done:
+		return false
+	}
+}
+
+// 在 hub 协程中直接添加通道，只能在 hub 协程中调用
+func (h *Hub) attach(producer interface{}, info producerInfo) {
+	if info.weight <= 0 {
+		info.weight = 1
+	}
+	h.countProducer(&info, 1)
+	if h.engine == EngineFunnel {
+		h.fn.attach(producer, info)
+		return
+	}
+
+	if h.quantum > 0 && info.level != priorityControl {
+		h.checkFair(info.level)
+	}
+	h.ps.add(reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(producer),
+	}, info)
+}
+
+// 在 hub 协程中直接移除通道，只能在 hub 协程中调用
+func (h *Hub) detach(producer interface{}) bool {
+	if h.engine == EngineFunnel {
+		return h.detachFunnel(producer)
+	}
+
+	for i := firstProducer; i < len(h.ps.cases); i++ {
+		if sameProducer(h.ps.cases[i].Chan.Interface(), producer) {
+			h.countProducer(&h.ps.infos[i], -1)
+			h.ps.remove(i)
+			return true
+		}
+	}
+	return false
+}
+
+// 处理各生产者通道中已排队的数据，只能在 hub 协程中调用
+//
+// 只处理调用时通道中已有的数据，处理中新写入的数据最多处理一条；已关闭的通道送出 ProducerClosed
+func (h *Hub) drain() {
+	if h.engine == EngineFunnel {
+		h.drainFunnel()
+		return
+	}
+
+	type queued struct {
+		ch    reflect.Value
+		n     int
+		level int
+	}
+
+	var chans []queued
+	for i := firstProducer; i < len(h.ps.cases); i++ {
+		ch := h.ps.cases[i].Chan
+		if ch.IsValid() && !ch.IsNil() {
+			// 多尝试接收一次，以发现已关闭的通道
+			n := ch.Len() + 1
+			chans = append(chans, queued{ch, n, h.ps.infos[i].level})
+		}
+	}
+	// 高优先级先处理
+	sort.SliceStable(chans, func(i, j int) bool { return chans[i].level > chans[j].level })
+
+	for _, q := range chans {
+		if i := h.indexOf(q.ch); i != -1 && h.batchSize > 1 && h.ps.infos[i].batchable() {
+			ch := q.ch
+			closed := h.drainBatch(ch, q.n, h.batch[:0], func() *producerInfo {
+				if i := h.indexOf(ch); i != -1 {
+					return &h.ps.infos[i]
+				}
+				return nil
+			})
+			if i := h.indexOf(ch); closed && i != -1 {
+				info := h.ps.infos[i]
+				h.ps.remove(i)
+				h.closed(&info, ch.Interface())
 			}
+			continue
 		}
 
+		for n := 0; n < q.n; n++ {
+			// 通道可能已在处理中被移除
+			i := h.indexOf(q.ch)
+			if i == -1 {
+				break
+			}
+
+			recv, ok := q.ch.TryRecv()
+			if !ok {
+				if recv.IsValid() {
+					// remove close chan
+					info := h.ps.infos[i]
+					h.ps.remove(i)
+					h.closed(&info, q.ch.Interface())
+				}
+				break
+			}
+			h.dispatch(&h.ps.infos[i], q.ch.Interface(), recv.Interface())
+		}
+	}
+}
+
+// 监听的通道数量，只能在 hub 协程中调用
+func (h *Hub) producerCount() int {
+	if h.engine == EngineFunnel {
+		return len(h.fn.entries)
+	}
+	return len(h.ps.cases) - firstProducer
+}
+
+func (h *Hub) indexOf(ch reflect.Value) int {
+	for i := firstProducer; i < len(h.ps.cases); i++ {
+		if h.ps.cases[i].Chan.Pointer() == ch.Pointer() {
+			return i
+		}
+	}
+	return -1
+}
+
+func (h *Hub) process(recovery int) {
+	atomic.StoreInt64(&h.recovery, int64(recovery))
+	defer func() {
+		r := recover()
+		if r == nil {
+			// 正常退出
+			h.exit(nil)
+			return
+		}
+
+		stack := debug.Stack()
 		flag := recovery != 0
 		if recovery > 0 {
 			recovery--
+			atomic.StoreInt64(&h.recovery, int64(recovery))
 		}
 
-		log.Warn().Bool("recovery", flag).Int("remain", recovery).Msg("panic recovery")
+		flag = h.onPanic(PanicInfo{
+			Group:     h.name,
+			Handler:   h.running,
+			Data:      h.data,
+			Value:     r,
+			Stack:     stack,
+			Remaining: recovery,
+			Recover:   flag,
+		})
+		h.observePanic(flag)
+		if h.onDead != nil && h.data != nil {
+			h.onDead(DeadPanic, h.running, h.source, h.data)
+		}
+		h.source = nil
+		h.mark("", nil)
+		h.idle()
 
 		if flag {
 			go h.process(recovery)
+			return
 		}
+
+		h.exit(&PanicError{Value: r, Stack: stack})
 	}()
 
+	id := goroutineID()
+	atomic.StoreInt64(&h.gid, id)
+	if h.watchdog != nil {
+		h.watchdog.attach(id)
+	}
+	if h.engine == EngineFunnel {
+		h.loopFunnel()
+	} else {
+		h.loopSelect()
+	}
+}
+
+// reflect.Select 监听所有通道，停止时返回
+func (h *Hub) loopSelect() {
 	for {
-		chosen, recv, recvOK := reflect.Select(cases)
+		h.idle()
+		// 高优先级通道先处理
+		chosen, recv, recvOK := h.ps.poll()
+		if chosen == -1 && h.quantum > 0 {
+			chosen, recv, recvOK = h.ps.fair(h.quantum)
+		}
+		if chosen == -1 && h.out.pending() {
+			// 有待发送数据时，同时等待目标通道可写
+			chosen, recv, recvOK = h.selectOut(h.ps.cases)
+			if chosen == -1 {
+				continue
+			}
+			if chosen >= len(h.ps.cases) {
+				h.out.sent(chosen - len(h.ps.cases))
+				continue
+			}
+		}
+		if chosen == -1 {
+			chosen, recv, recvOK = reflect.Select(h.ps.cases)
+		}
+		if chosen == quitCase {
+			return
+		}
+		if chosen == controlCase && !recvOK {
+			// 控制通道关闭，停止后退出
+			h.stop(ErrGroupStopped)
+			return
+		}
 		if !recvOK {
 			// remove close chan
-			cases = append(cases[:chosen], cases[chosen+1:]...)
+			info, producer := h.ps.infos[chosen], h.ps.cases[chosen].Chan.Interface()
+			h.ps.remove(chosen)
+			h.closed(&info, producer)
 			continue
 		}
 
 		switch value := recv.Interface().(type) {
 		case producerOp:
-			if value.op == addProducer {
-				// append case
-				cases = append(cases, reflect.SelectCase{
-					Dir:  reflect.SelectRecv,
-					Chan: reflect.ValueOf(value.producer),
-				})
-				if value.cb != nil {
-					value.cb()
-				}
-				log.Trace().Int("len", len(cases)).Msg("append producer")
-			} else if value.op == removeProducer {
-				// remove case
-				var removed bool
-				for i, e := range cases {
-					t, ok := e.Chan.Interface().(chan interface{})
-					if ok && t == value.producer {
-						cases = append(cases[:i], cases[i+1:]...)
-						removed = true
-						break
-					}
-				}
-				log.Trace().Int("len", len(cases)).Bool("removed", removed).Bool("cb", value.cb != nil).Msg("remove producer")
-				if removed && value.cb != nil {
-					value.cb()
-				}
-			}
+			h.control(value)
 		default:
-			// 执行高风险调用前，先备份一次
-			h.keep.Store(cases)
-			// 调用自定义处理
-			data := recv.Interface()
-			cursor := h.processors.Cursor()
-			for data != nil && cursor.Next() {
-				data = cursor.Value().OnData(data)
+			if h.batchSize > 1 && h.ps.infos[chosen].batchable() {
+				h.batchSelect(chosen, value)
+				continue
 			}
+			h.dispatch(&h.ps.infos[chosen], h.ps.cases[chosen].Chan.Interface(), value)
+		}
+	}
+}
+
+// 执行通道操作
+func (h *Hub) control(value producerOp) {
+	h.source = nil
+	h.mark("control", nil)
+	if value.op == addProducer {
+		// append case
+		h.attach(value.producer, value.info)
+		if value.cb != nil {
+			value.cb()
+		}
+		h.log.trace("append producer", "len", h.producerCount())
+	} else if value.op == removeProducer {
+		// remove case
+		removed := h.detach(value.producer)
+		h.log.trace("remove producer", "len", h.producerCount(), "removed", removed, "cb", value.cb != nil)
+		if removed && value.cb != nil {
+			value.cb()
 		}
+	} else if value.op == execOp {
+		value.cb()
 	}
 }
 
-// 停止
+// 处理通道 source 收到的数据，info.handler 非 nil 时直接处理，否则经过处理链
+func (h *Hub) dispatch(info *producerInfo, source, data interface{}) {
+	info.received++
+	if info.level != priorityControl {
+		atomic.AddUint64(&h.metrics.received, 1)
+	}
+	h.source = source
+	h.mark("", data)
+	if info.handler != nil {
+		info.handler(data)
+		return
+	}
+
+	// 调用自定义处理，IEnvelopeProcessor 收到带来源信息的数据
+	var env *Envelope
+	cursor := h.processors.Cursor()
+	for data != nil && cursor.Next() {
+		p := cursor.Value()
+		name := p.Name()
+		h.mark(name, data)
+		start := time.Now()
+		ep, ok := p.(IEnvelopeProcessor)
+		if !ok {
+			data = p.OnData(data)
+			h.observeProcessor(p, name, start)
+			continue
+		}
+
+		if env == nil {
+			env = &Envelope{Producer: source, Meta: info.meta, Time: h.now(), Seq: info.received}
+		}
+		env.Data = data
+		data = ep.OnEnvelope(env)
+		h.observeProcessor(p, name, start)
+	}
+
+	if _, closed := data.(ProducerClosed); data != nil && !closed && h.onDead != nil {
+		h.onDead(DeadUnhandled, "", h.source, data)
+	}
+}
+
+// 通道关闭并已移除，经处理链送出 ProducerClosed
+func (h *Hub) closed(info *producerInfo, producer interface{}) {
+	h.countProducer(info, -1)
+	if info.handler != nil {
+		// 内部通道或 AttachTyped 添加的通道，不经过处理链
+		return
+	}
+
+	h.log.trace("producer closed", "len", h.producerCount())
+	h.dispatch(info, producer, ProducerClosed{Producer: producer, Meta: info.meta})
+}
+
+// 取出各生产者通道中剩余的数据，作为死信报告，只能在 hub 协程中调用
+func (h *Hub) discard() {
+	var chans []reflect.Value
+	if h.engine == EngineFunnel {
+		for _, e := range h.fn.entries {
+			if !e.internal() {
+				chans = append(chans, e.ch)
+			}
+		}
+	} else {
+		for i := firstProducer; i < len(h.ps.cases); i++ {
+			if !h.ps.infos[i].internal() {
+				chans = append(chans, h.ps.cases[i].Chan)
+			}
+		}
+	}
+
+	for _, ch := range chans {
+		if !ch.IsValid() || ch.IsNil() {
+			continue
+		}
+
+		producer := ch.Interface()
+		for n := ch.Len(); n > 0; n-- {
+			recv, ok := ch.TryRecv()
+			if !ok {
+				break
+			}
+			h.onDead(DeadGroupStopped, "", producer, recv.Interface())
+		}
+	}
+}
+
+// 标记正在执行的处理，用于 panic 和处理超时报告，只能在 hub 协程中调用
+func (h *Hub) mark(running string, data interface{}) {
+	h.running, h.data = running, data
+	if h.watchdog != nil {
+		h.watchdog.track(running, data)
+	}
+}
+
+// 处理完成，开始等待数据，只能在 hub 协程中调用
+func (h *Hub) idle() {
+	if h.watchdog != nil {
+		h.watchdog.idle()
+	}
+}
+
+// 停止，hub 协程处理完当前数据后退出
 func (h *Hub) Stop() {
-	// 关闭add通道
-	close(h.producer)
+	h.stop(ErrGroupStopped)
+}
+
+// 停止，并记录停止原因，只有第一次调用的原因有效
+func (h *Hub) stop(cause error) {
+	h.quitOnce.Do(func() {
+		h.mu.Lock()
+		h.cause = cause
+		h.mu.Unlock()
+
+		h.working.Store(false)
+		close(h.quit)
+	})
+}
+
+// hub 协程退出，err 为 nil 时退出原因为停止原因
+func (h *Hub) exit(err error) {
+	// 先标记停止，再取出剩余数据，此后投递成功的一方自行取出，见 Group.post
 	h.working.Store(false)
+	if h.engine == EngineFunnel {
+		// 停止转发协程，已取出的数据作为死信
+		for _, m := range h.fn.haltAll() {
+			if h.onDead != nil && !m.entry.internal() {
+				h.onDead(DeadGroupStopped, "", m.entry.producer, m.data)
+			}
+		}
+	}
+	if h.onDead != nil {
+		h.discard()
+		h.discardOutbox()
+	}
+
+	h.mu.Lock()
+	if err == nil {
+		err = h.cause
+	}
+	if err == nil {
+		// 控制通道被关闭
+		err = ErrGroupStopped
+	}
+	h.err = err
+	h.mu.Unlock()
+
+	if h.onExit != nil {
+		h.onExit()
+	}
+	close(h.done)
+}
+
+// hub 协程退出后关闭
+func (h *Hub) Done() <-chan struct{} {
+	return h.done
+}
+
+// 退出原因，hub 协程未退出时返回 nil
+// 	ErrGroupStopped 正常停止
+// 	*PanicError panic 超出恢复次数
+// 	其他为停止时指定的原因，如 context.Canceled
+func (h *Hub) Err() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
 }
 
 // 工作中
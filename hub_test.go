@@ -10,7 +10,6 @@ func data1(ch chan interface{}) {
 	go func() {
 		for i := 0; i < 10; i++ {
 			ch <- i
-			time.Sleep(time.Second)
 		}
 		close(ch)
 	}()
@@ -20,19 +19,21 @@ func data2(ch chan interface{}) {
 	go func() {
 		for i := 'a'; i < 'z'; i++ {
 			ch <- string([]byte{byte(i)})
-			time.Sleep(time.Second)
 		}
 		close(ch)
 	}()
 }
 
-type tHandleData struct{}
+type tHandleData struct {
+	done chan interface{}
+}
 
 func (tHandleData) Name() string {
 	return "tHandleData"
 }
-func (tHandleData) OnData(data interface{}) interface{} {
+func (h tHandleData) OnData(data interface{}) interface{} {
 	fmt.Println("tHandleData:", data)
+	h.done <- data
 	return data
 }
 
@@ -40,7 +41,8 @@ func TestHub(t *testing.T) {
 	ch1 := make(chan interface{}, 1)
 	ch2 := make(chan interface{}, 1)
 
-	h := newHub(12, 0, &tHandleData{})
+	done := make(chan interface{}, 1)
+	h := newHub(hubconfig{Name: "hub", ProducerLen: 12}, &tHandleData{done: done})
 
 	h.Add(ch1, nil)
 	h.Add(ch2, nil)
@@ -48,12 +50,19 @@ func TestHub(t *testing.T) {
 	data1(ch1)
 	data2(ch2)
 
-	time.Sleep(time.Second * 30)
+	// 等待两个通道的数据都处理完
+	for n := 0; n < 10+25; n++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second * 5):
+			t.Fatalf("timeout, %d processed", n)
+		}
+	}
 }
 
 func TestRemoveProducer(t *testing.T) {
 	ch1 := make(chan interface{}, 1)
-	h := newHub(12, 0)
+	h := newHub(hubconfig{Name: "hub", ProducerLen: 12})
 	h.Add(ch1, func() {
 		t.Log("producer removed")
 	})
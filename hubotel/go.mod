@@ -0,0 +1,21 @@
+module github.com/goSeeFuture/hub/hubotel
+
+go 1.21
+
+require (
+	github.com/goSeeFuture/hub v0.0.0
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/sdk v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/rs/zerolog v1.20.0 // indirect
+	go.opentelemetry.io/otel/metric v1.24.0 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+)
+
+// 与 hub 同仓库开发，发布时替换为 hub 的版本
+replace github.com/goSeeFuture/hub => ../
@@ -0,0 +1,66 @@
+// hubotel 将 OpenTelemetry 的 trace.Tracer 适配为 hub.Tracer
+package hubotel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/goSeeFuture/hub"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 适配 OpenTelemetry，配合 hub.GroupTracer 使用
+//
+//	tracer := hubotel.NewTracer(otel.Tracer("hub"))
+//	g := hub.NewGroup(hub.GroupTracer(tracer))
+func NewTracer(t trace.Tracer) hub.Tracer {
+	return tracer{t}
+}
+
+type tracer struct {
+	t trace.Tracer
+}
+
+func (t tracer) Start(ctx context.Context, name string, start time.Time, attrs ...interface{}) (context.Context, hub.Span) {
+	ctx, span := t.t.Start(ctx, name, trace.WithTimestamp(start), trace.WithAttributes(attributes(attrs)...))
+	return ctx, otelSpan{span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+// 结束 span，err 非 nil 时记录错误并标记为失败
+func (s otelSpan) End(end time.Time, err error) {
+	if err != nil {
+		s.span.RecordError(err, trace.WithTimestamp(end))
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End(trace.WithTimestamp(end))
+}
+
+// 转换成对的键、值
+func attributes(attrs []interface{}) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, 0, len(attrs)/2)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		key := attribute.Key(fmt.Sprint(attrs[i]))
+		switch v := attrs[i+1].(type) {
+		case string:
+			kvs = append(kvs, key.String(v))
+		case int:
+			kvs = append(kvs, key.Int(v))
+		case int64:
+			kvs = append(kvs, key.Int64(v))
+		case bool:
+			kvs = append(kvs, key.Bool(v))
+		case float64:
+			kvs = append(kvs, key.Float64(v))
+		default:
+			kvs = append(kvs, key.String(fmt.Sprint(v)))
+		}
+	}
+	return kvs
+}
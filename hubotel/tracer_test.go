@@ -0,0 +1,49 @@
+package hubotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/goSeeFuture/hub"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otelTracer := provider.Tracer("hub")
+
+	g := hub.NewGroup(hub.GroupName("otel"), hub.GroupTracer(NewTracer(otelTracer)))
+	g.ListenCall("query", func(arg interface{}) hub.Return {
+		return hub.Return{Error: errors.New("not found")}
+	})
+
+	ctx, root := otelTracer.Start(context.Background(), "root")
+	g.CallContext(ctx, "query", nil)
+	root.End()
+	g.Stop()
+	<-g.Done()
+
+	spans := exporter.GetSpans()
+	byName := map[string]tracetest.SpanStub{}
+	for _, s := range spans {
+		byName[s.Name] = s
+	}
+	rootSpan := byName["root"]
+	for _, name := range []string{"queue:call:query", "call:query"} {
+		s, ok := byName[name]
+		if !ok || s.Parent.SpanID() != rootSpan.SpanContext.SpanID() ||
+			s.SpanContext.TraceID() != rootSpan.SpanContext.TraceID() {
+			t.Fatalf("%s in %+v", name, spans)
+		}
+		if len(s.Attributes) != 1 || s.Attributes[0].Key != hub.AttrGroup || s.Attributes[0].Value.AsString() != "otel" {
+			t.Fatalf("%s attributes %v", name, s.Attributes)
+		}
+	}
+	if s := byName["call:query"]; s.Status.Code != codes.Error || s.Status.Description != "not found" || len(s.Events) != 1 {
+		t.Fatalf("call span %+v", s)
+	}
+}
@@ -0,0 +1,128 @@
+// hubtest 提供测试 hub.Group 的工具
+package hubtest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/goSeeFuture/hub"
+)
+
+// 手动拨动的时钟，实现 hub.Clock
+//
+// 配合 hub.GroupClock 使用，Advance 拨动时间后，到期的定时器在 group 协程中执行，
+// 再调用 Flush 等待执行完毕，无需真实等待。回调中读取的 Now 可能已被后续 Advance 拨动，
+// 每次 Advance 后 Flush 可保证回调看到的时间一致
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+// 构建时钟，起始时间为 now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// 当前时间
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// 创建 ticker
+func (c *FakeClock) NewTicker(d time.Duration) hub.Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTicker{
+		clock: c,
+		c:     make(chan time.Time),
+	}
+	t.reset(c.now, d)
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// 拨动时间 d，并触发到期的 ticker
+//
+// 阻塞到所有到期的 ticker 通道被接收后返回；同一 ticker 多次到期只触发一次，同 time.Ticker
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+
+	type due struct {
+		c    chan time.Time
+		stop chan struct{}
+		at   time.Time
+	}
+	var fires []due
+	for _, t := range c.tickers {
+		if t.stop == nil || t.next.After(now) {
+			continue
+		}
+
+		fires = append(fires, due{t.c, t.stop, t.next})
+		for !t.next.After(now) {
+			t.next = t.next.Add(t.period)
+		}
+	}
+	c.mu.Unlock()
+
+	sort.SliceStable(fires, func(i, j int) bool {
+		return fires[i].at.Before(fires[j].at)
+	})
+	for _, f := range fires {
+		select {
+		case f.c <- now:
+		case <-f.stop:
+		}
+	}
+}
+
+type fakeTicker struct {
+	clock  *FakeClock
+	c      chan time.Time
+	period time.Duration
+	next   time.Time
+	stop   chan struct{} // 停止时关闭，nil 表示已停止
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	if t.stop != nil {
+		close(t.stop)
+		t.stop = nil
+	}
+}
+
+func (t *fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("non-positive interval for Ticker.Reset")
+	}
+
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.reset(t.clock.now, d)
+}
+
+func (t *fakeTicker) reset(now time.Time, d time.Duration) {
+	if t.stop == nil {
+		t.stop = make(chan struct{})
+	}
+	t.period = d
+	t.next = now.Add(d)
+}
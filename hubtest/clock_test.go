@@ -0,0 +1,78 @@
+package hubtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goSeeFuture/hub"
+)
+
+func TestFakeClock(t *testing.T) {
+	clock := NewFakeClock(time.Date(2021, 3, 5, 10, 0, 0, 0, time.UTC))
+	g := hub.NewGroup(hub.GroupClock(clock))
+	defer g.Stop()
+
+	t.Run("AfterFunc", func(t *testing.T) {
+		var fired int
+		g.AfterFunc(time.Minute, func() { fired++ })
+
+		clock.Advance(59 * time.Second)
+		Flush(g)
+		if fired != 0 {
+			t.Fatal("fired early")
+		}
+
+		clock.Advance(time.Second)
+		Flush(g)
+		if fired != 1 {
+			t.Fatalf("want 1 fire, got %d", fired)
+		}
+	})
+
+	t.Run("Tick", func(t *testing.T) {
+		var ticks int
+		tm := g.Tick(time.Second, func() bool {
+			ticks++
+			return true
+		})
+
+		for i := 0; i < 5; i++ {
+			clock.Advance(time.Second)
+			Flush(g)
+		}
+		if ticks != 5 {
+			t.Fatalf("want 5 ticks, got %d", ticks)
+		}
+
+		tm.Stop()
+		clock.Advance(time.Second)
+		Flush(g)
+		if ticks != 5 {
+			t.Fatalf("ticked after stop: %d", ticks)
+		}
+	})
+
+	t.Run("Schedule", func(t *testing.T) {
+		var runs []time.Time
+		job, err := g.Schedule("*/10 * * * *", func() {
+			runs = append(runs, clock.Now())
+		}, hub.ScheduleLocation(time.UTC))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer job.Stop()
+
+		for i := 0; i < 30; i++ {
+			clock.Advance(time.Minute)
+			Flush(g)
+		}
+		if len(runs) != 3 {
+			t.Fatalf("want 3 runs, got %v", runs)
+		}
+		for _, at := range runs {
+			if at.Minute()%10 != 0 {
+				t.Fatalf("run at %v", at)
+			}
+		}
+	})
+}
@@ -0,0 +1,21 @@
+package hubtest
+
+import "github.com/goSeeFuture/hub"
+
+// 等待 group 协程处理完调用前已接收的数据
+//
+// 常用于 FakeClock.Advance 之后，确认到期的定时器已经执行；不可在 group 协程中调用
+// group 已停止时立即返回
+func Flush(g *hub.Group) {
+	done := make(chan struct{})
+	g.SlowCall(func(interface{}) hub.Return {
+		return hub.Return{}
+	}, nil, func(hub.Return) {
+		close(done)
+	})
+
+	select {
+	case <-done:
+	case <-g.Done():
+	}
+}
@@ -0,0 +1,104 @@
+package hubtest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/goSeeFuture/hub"
+)
+
+// 已结束的 span
+type SpanRecord struct {
+	Name   string
+	Trace  uint64 // 根 span 的 ID
+	ID     uint64
+	Parent uint64 // 父 span 的 ID，根 span 为0
+	Start  time.Time
+	End    time.Time
+	Attrs  map[string]interface{}
+	Err    error
+}
+
+// 在内存中记录 span，实现 hub.Tracer
+//
+// 配合 hub.GroupTracer 使用，测试 Emit、Call、SlowCall 的调用链；
+// 调用方以 Start 开始根 span，把返回的 ctx 传给 EmitContext 等
+type SpanRecorder struct {
+	mu    sync.Mutex
+	next  uint64
+	spans []SpanRecord
+}
+
+// 构建记录器
+func NewSpanRecorder() *SpanRecorder {
+	return &SpanRecorder{}
+}
+
+type spanKey struct{}
+
+type recordingSpan struct {
+	r    *SpanRecorder
+	data SpanRecord
+	once sync.Once
+}
+
+// 开始 span，ctx 中有此记录器的 span 时作为父 span
+func (r *SpanRecorder) Start(ctx context.Context, name string, start time.Time, attrs ...interface{}) (context.Context, hub.Span) {
+	r.mu.Lock()
+	r.next++
+	id := r.next
+	r.mu.Unlock()
+
+	s := &recordingSpan{r: r, data: SpanRecord{Name: name, Trace: id, ID: id, Start: start}}
+	if parent, ok := ctx.Value(spanKey{}).(*recordingSpan); ok && parent.r == r {
+		s.data.Trace, s.data.Parent = parent.data.Trace, parent.data.ID
+	}
+	if len(attrs) > 0 {
+		s.data.Attrs = make(map[string]interface{}, len(attrs)/2)
+		for i := 0; i+1 < len(attrs); i += 2 {
+			if key, ok := attrs[i].(string); ok {
+				s.data.Attrs[key] = attrs[i+1]
+			}
+		}
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// 结束 span，重复调用无效
+func (s *recordingSpan) End(end time.Time, err error) {
+	s.once.Do(func() {
+		s.data.End, s.data.Err = end, err
+		s.r.mu.Lock()
+		s.r.spans = append(s.r.spans, s.data)
+		s.r.mu.Unlock()
+	})
+}
+
+// 已结束的 span，按结束顺序
+func (r *SpanRecorder) Spans() []SpanRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]SpanRecord(nil), r.spans...)
+}
+
+// 已结束的名为 name 的 span
+func (r *SpanRecorder) Find(name string) []SpanRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var spans []SpanRecord
+	for _, s := range r.spans {
+		if s.Name == name {
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
+// 清空已记录的 span
+func (r *SpanRecorder) Reset() {
+	r.mu.Lock()
+	r.spans = nil
+	r.mu.Unlock()
+}
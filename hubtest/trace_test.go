@@ -0,0 +1,138 @@
+package hubtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/goSeeFuture/hub"
+)
+
+func TestSpanRecorder(t *testing.T) {
+	tracer := NewSpanRecorder()
+	g := hub.NewGroup(hub.GroupName("trace"), hub.GroupTracer(tracer))
+	defer g.Stop()
+
+	// Call → handler → SlowCall → callback → Emit
+	done := make(chan struct{})
+	g.ListenEvent("done", func(interface{}) { close(done) })
+	g.ListenCall("query", func(arg interface{}) hub.Return {
+		g.SlowCallContext(g.HandlerContext(), func(ctx context.Context, arg interface{}) hub.Return {
+			return hub.Return{Error: errors.New("slow")}
+		}, arg, func(hub.Return) {
+			g.EmitContext(g.HandlerContext(), "done", nil)
+		})
+		return hub.Return{Value: arg}
+	})
+
+	ctx, root := tracer.Start(context.Background(), "root", time.Now())
+	if ret, _ := g.CallContext(ctx, "query", 1); ret.Value != 1 {
+		t.Fatalf("return %+v", ret)
+	}
+	<-done
+	Flush(g)
+	root.End(time.Now(), nil)
+
+	// Flush 的 SlowCall 不属于该调用链
+	one := func(name string) SpanRecord {
+		var found []SpanRecord
+		for _, s := range tracer.Find(name) {
+			if s.Trace == 1 {
+				found = append(found, s)
+			}
+		}
+		if len(found) != 1 {
+			t.Fatalf("%s spans %+v", name, tracer.Spans())
+		}
+		return found[0]
+	}
+	rootSpan := one("root")
+	call := one("call:query")
+	callback := one(hub.SpanSlowCallback)
+	for name, parent := range map[string]SpanRecord{
+		"queue:call:query":          rootSpan,
+		"call:query":                rootSpan,
+		"queue:" + hub.SpanSlowCall: call,
+		hub.SpanSlowCall:            call,
+		hub.SpanSlowCallback:        call,
+		"queue:event:done":          callback,
+		"event:done":                callback,
+	} {
+		s := one(name)
+		if s.Trace != rootSpan.ID || s.Parent != parent.ID || s.Attrs[hub.AttrGroup] != "trace" || s.End.Before(s.Start) {
+			t.Fatalf("%s %+v, parent %+v", name, s, parent)
+		}
+	}
+	if s := one(hub.SpanSlowCall); s.Err == nil || s.Err.Error() != "slow" {
+		t.Fatalf("slow call %+v", s)
+	}
+
+	// 未传入 ctx 时为根 span
+	tracer.Reset()
+	g.ListenEvent("alone", func(interface{}) {})
+	g.Emit("alone", nil)
+	Flush(g)
+	if s := tracer.Find("event:alone"); len(s) != 1 || s[0].Parent != 0 {
+		t.Fatalf("alone %+v", s)
+	}
+}
+
+func TestSpanRecorderPanic(t *testing.T) {
+	tracer := NewSpanRecorder()
+	g := hub.NewGroup(hub.GroupTracer(tracer), hub.GroupRecovery(1),
+		hub.GroupPanicHandler(func(info hub.PanicInfo) bool { return true }))
+	defer g.Stop()
+
+	g.ListenCall("crash", func(interface{}) hub.Return { panic("crash") })
+	g.Call("crash", nil)
+	Flush(g)
+
+	spans := tracer.Find("call:crash")
+	var pe *hub.PanicError
+	if len(spans) != 1 || !errors.As(spans[0].Err, &pe) || pe.Value != "crash" {
+		t.Fatalf("spans %+v", tracer.Spans())
+	}
+}
+
+// 处理链中的处理器，把数据加1交给下一个处理器，out 非 nil 时发送到 out
+type incProcessor struct {
+	name string
+	out  chan int
+}
+
+func (p incProcessor) Name() string {
+	return p.name
+}
+
+func (p incProcessor) OnData(data interface{}) interface{} {
+	v := data.(int) + 1
+	if p.out != nil {
+		p.out <- v
+		return nil
+	}
+	return v
+}
+
+func TestSpanRecorderProcessor(t *testing.T) {
+	tracer := NewSpanRecorder()
+	got := make(chan int, 1)
+	g := hub.NewGroup(hub.GroupName("chain"), hub.GroupTracer(tracer),
+		hub.GroupHandles(incProcessor{name: "first"}, incProcessor{name: "second", out: got}))
+	defer g.Stop()
+
+	ch := make(chan int)
+	g.Attach(ch)
+	ch <- 1
+	if v := <-got; v != 3 {
+		t.Fatalf("got %v", v)
+	}
+	Flush(g)
+
+	for _, name := range []string{"first", "second"} {
+		s := tracer.Find(hub.SpanProcessorPrefix + name)
+		if len(s) != 1 || s[0].Parent != 0 || s[0].Attrs[hub.AttrGroup] != "chain" || s[0].End.Before(s[0].Start) {
+			t.Fatalf("%s spans %+v", name, tracer.Spans())
+		}
+	}
+}
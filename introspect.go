@@ -0,0 +1,141 @@
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+)
+
+// Group 运行状态快照
+type GroupInfo struct {
+	Name       string   `json:"name"`
+	Working    bool     `json:"working"`
+	Err        string   `json:"error,omitempty"`     // 退出原因
+	Recovery   int      `json:"recovery"`            // 剩余恢复次数，-1 表示总是恢复
+	Processors string   `json:"processors"`          // 处理链，同 Queue.String
+	Events     []string `json:"events"`              // ListenEvent 注册的事件名称
+	Calls      []string `json:"calls"`               // ListenCall 注册的调用名称
+	Producers  int      `json:"producers"`           // 监听的通道数量
+	Timers     int      `json:"timers"`              // 未执行的定时器数量
+	Inbox      int      `json:"inbox"`               // 收件箱中排队的 Emit、Call、SlowCall 数量
+	Delegated  *bool    `json:"delegated,omitempty"` // 委托工作组是否已委托，其他 Group 为 nil
+}
+
+// 运行中的 Group，构建时登记，退出后移除
+type groupRegistry struct {
+	mu     sync.Mutex
+	groups map[*Group]*groupDelegate // 委托工作组的值非 nil
+}
+
+var groups = groupRegistry{groups: make(map[*Group]*groupDelegate)}
+
+// 登记 g，g 已停止时不登记
+func (r *groupRegistry) add(g *Group, gd *groupDelegate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if g.IsWorking() {
+		r.groups[g] = gd
+	}
+}
+
+func (r *groupRegistry) remove(g *Group) {
+	r.mu.Lock()
+	delete(r.groups, g)
+	r.mu.Unlock()
+}
+
+// 运行状态快照，可在任意协程中调用，不会等待 group 协程
+func (g *Group) Info() GroupInfo {
+	groups.mu.Lock()
+	gd := groups.groups[g]
+	groups.mu.Unlock()
+	return g.info(gd)
+}
+
+func (g *Group) info(gd *groupDelegate) GroupInfo {
+	info := GroupInfo{
+		Name:       g.Name(),
+		Working:    g.IsWorking(),
+		Recovery:   int(atomic.LoadInt64(&g.hub.recovery)),
+		Processors: g.hub.processors.String(),
+		Events:     syncMapKeys(&g.events),
+		Calls:      syncMapKeys(&g.calls),
+		Producers:  int(atomic.LoadInt64(&g.hub.metrics.producers)),
+		Timers:     g.timers.pending(),
+		Inbox:      len(g.processChan),
+	}
+	if err := g.Err(); err != nil {
+		info.Err = err.Error()
+	}
+	if gd != nil {
+		delegated := gd.IsDelegated()
+		info.Delegated = &delegated
+	}
+	return info
+}
+
+// 已排序的键
+func syncMapKeys(m *sync.Map) []string {
+	keys := []string{}
+	m.Range(func(key, _ interface{}) bool {
+		keys = append(keys, key.(string))
+		return true
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// 所有运行中的 Group 的状态，按名称排序，可在任意协程中调用
+func Groups() []GroupInfo {
+	groups.mu.Lock()
+	list := make([]*Group, 0, len(groups.groups))
+	delegates := make([]*groupDelegate, 0, len(groups.groups))
+	for g, gd := range groups.groups {
+		list = append(list, g)
+		delegates = append(delegates, gd)
+	}
+	groups.mu.Unlock()
+
+	// 不持有锁读取状态
+	infos := make([]GroupInfo, len(list))
+	for i, g := range list {
+		infos[i] = g.info(delegates[i])
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// 列出运行中的 Group，可挂载到管理端口
+// 	默认输出文本表格，?format=json 或 Accept: application/json 时输出 JSON
+func IntrospectHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		infos := Groups()
+		if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			json.NewEncoder(w).Encode(infos)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tWORKING\tRECOVERY\tPRODUCERS\tTIMERS\tINBOX\tDELEGATED\tPROCESSORS\tEVENTS\tCALLS")
+		for _, info := range infos {
+			delegated := "-"
+			if info.Delegated != nil {
+				delegated = fmt.Sprint(*info.Delegated)
+			}
+			fmt.Fprintf(tw, "%s\t%t\t%d\t%d\t%d\t%d\t%s\t%s\t%s\t%s\n",
+				info.Name, info.Working, info.Recovery, info.Producers, info.Timers, info.Inbox, delegated,
+				info.Processors, strings.Join(info.Events, ","), strings.Join(info.Calls, ","))
+		}
+		tw.Flush()
+	})
+}
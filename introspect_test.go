@@ -0,0 +1,96 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 按名称查找运行中的 Group
+func findGroup(name string) (GroupInfo, bool) {
+	for _, info := range Groups() {
+		if info.Name == name {
+			return info, true
+		}
+	}
+	return GroupInfo{}, false
+}
+
+func Test_Introspect(t *testing.T) {
+	g := NewGroup(GroupName("introspect"), GroupRecovery(2), GroupHandles(&tOrderProcessor{}))
+	g.ListenEvent("login", func(interface{}) {})
+	g.ListenEvent("logout", func(interface{}) {})
+	g.ListenCall("query", func(interface{}) Return { return Return{} })
+	g.AfterFunc(time.Hour, func() {})
+	ch := make(chan int)
+	g.Attach(ch)
+
+	gd := newGroupDelegate(GroupName("introspect-delegate"))
+
+	// 与运行中的 Group 并发读取
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				Groups()
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		g.Emit("login", nil)
+	}
+	close(stop)
+
+	info, ok := findGroup("introspect")
+	if !ok || !info.Working || info.Recovery != 2 || info.Processors != "introspect/tOrderProcessor" ||
+		strings.Join(info.Events, ",") != "login,logout" || strings.Join(info.Calls, ",") != "query" ||
+		info.Producers != 1 || info.Timers != 1 || info.Delegated != nil {
+		t.Fatalf("info %+v", info)
+	}
+	if info, ok := findGroup("introspect-delegate"); !ok || info.Delegated == nil || *info.Delegated {
+		t.Fatalf("delegate %+v", info)
+	}
+
+	w := httptest.NewRecorder()
+	IntrospectHandler().ServeHTTP(w, httptest.NewRequest("GET", "/groups?format=json", nil))
+	var infos []GroupInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &infos); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, info := range infos {
+		found = found || info.Name == "introspect" && info.Processors == "introspect/tOrderProcessor"
+	}
+	if !found {
+		t.Fatalf("json %s", w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	IntrospectHandler().ServeHTTP(w, httptest.NewRequest("GET", "/groups", nil))
+	if body := w.Body.String(); !strings.HasPrefix(body, "NAME ") || !strings.Contains(body, "login,logout") {
+		t.Fatalf("text\n%s", body)
+	}
+
+	// 退出后移除
+	g.Detach(ch)
+	if err := g.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	gd.Stop()
+	<-gd.Done()
+	if _, ok := findGroup("introspect"); ok {
+		t.Fatal("stopped group listed")
+	}
+	if _, ok := findGroup("introspect-delegate"); ok {
+		t.Fatal("stopped delegate listed")
+	}
+	if info := g.Info(); info.Working || info.Err != ErrGroupStopped.Error() {
+		t.Fatalf("info %+v", info)
+	}
+}
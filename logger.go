@@ -0,0 +1,164 @@
+package hub
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// 日志级别，数值与 slog.Level 一致
+type Level int
+
+const (
+	LevelTrace Level = -8
+	LevelDebug Level = Level(slog.LevelDebug)
+	LevelInfo  Level = Level(slog.LevelInfo)
+	LevelWarn  Level = Level(slog.LevelWarn)
+	LevelError Level = Level(slog.LevelError)
+)
+
+func (l Level) String() string {
+	if l == LevelTrace {
+		return "TRACE"
+	}
+	return slog.Level(l).String()
+}
+
+// 日志输出
+type Logger interface {
+	// fields 为成对的键、值，总是包含 group
+	Log(level Level, msg string, fields ...interface{})
+}
+
+// 将 slog.Handler 包装为 Logger
+func SlogLogger(h slog.Handler) Logger {
+	return slogLogger{h}
+}
+
+type slogLogger struct {
+	h slog.Handler
+}
+
+func (l slogLogger) Log(level Level, msg string, fields ...interface{}) {
+	ctx := context.Background()
+	if !l.h.Enabled(ctx, slog.Level(level)) {
+		return
+	}
+
+	r := slog.NewRecord(time.Now(), slog.Level(level), msg, 0)
+	r.Add(fields...)
+	l.h.Handle(ctx, r)
+}
+
+// 将 zerolog.Logger 包装为 Logger
+func ZerologLogger(l zerolog.Logger) Logger {
+	return zerologLogger{l: &l, min: LevelTrace}
+}
+
+type zerologLogger struct {
+	l   *zerolog.Logger
+	min Level // 低于该级别的日志不输出
+}
+
+func (l zerologLogger) Log(level Level, msg string, fields ...interface{}) {
+	if level < l.min {
+		return
+	}
+
+	var zl zerolog.Level
+	switch {
+	case level < LevelDebug:
+		zl = zerolog.TraceLevel
+	case level < LevelInfo:
+		zl = zerolog.DebugLevel
+	case level < LevelWarn:
+		zl = zerolog.InfoLevel
+	case level < LevelError:
+		zl = zerolog.WarnLevel
+	default:
+		zl = zerolog.ErrorLevel
+	}
+
+	e := l.l.WithLevel(zl)
+	if e == nil {
+		return // 级别未启用
+	}
+	m := make(map[string]interface{}, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		m[fmt.Sprint(fields[i])] = fields[i+1]
+	}
+	e.Fields(m).Msg(msg)
+}
+
+// 未指定 GroupLogger 时使用 zerolog 的全局 log.Logger，不修改其配置，不输出 Trace 级别
+var defaultLogger Logger = zerologLogger{l: &log.Logger, min: LevelDebug}
+
+// 转换 GroupLogger 的参数
+func toLogger(v interface{}) Logger {
+	switch l := v.(type) {
+	case nil:
+		return defaultLogger
+	case Logger:
+		return l
+	case slog.Handler:
+		return SlogLogger(l)
+	case *slog.Logger:
+		return SlogLogger(l.Handler())
+	case zerolog.Logger:
+		return ZerologLogger(l)
+	case *zerolog.Logger:
+		return zerologLogger{l: l, min: LevelTrace}
+	}
+	panic(fmt.Sprintf("hub: unsupported logger %T", v))
+}
+
+// 带组名的日志
+type logger struct {
+	out   Logger
+	group string
+}
+
+func (l logger) log(level Level, msg string, fields ...interface{}) {
+	l.out.Log(level, msg, append([]interface{}{"group", l.group}, fields...)...)
+}
+
+func (l logger) trace(msg string, fields ...interface{}) {
+	l.log(LevelTrace, msg, fields...)
+}
+
+func (l logger) debug(msg string, fields ...interface{}) {
+	l.log(LevelDebug, msg, fields...)
+}
+
+func (l logger) warn(msg string, fields ...interface{}) {
+	l.log(LevelWarn, msg, fields...)
+}
+
+func (l logger) error(msg string, fields ...interface{}) {
+	l.log(LevelError, msg, fields...)
+}
+
+// 默认的 panic 处理，记录日志，按恢复次数决定是否恢复
+func (l logger) panic(info PanicInfo) bool {
+	l.error("panic",
+		"handler", info.Handler,
+		"panic", fmt.Sprint(info.Value),
+		"recovery", info.Recover,
+		"remain", info.Remaining,
+		"stack", string(info.Stack))
+	return info.Recover
+}
+
+// 默认的处理超时报告，记录日志
+func (l logger) slow(r WatchdogReport) {
+	l.warn("slow handler",
+		"handler", r.Handler,
+		"data", r.DataType,
+		"elapsed", r.Elapsed,
+		"repeat", r.Repeat,
+		"stack", string(r.Stack))
+}
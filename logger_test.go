@@ -0,0 +1,85 @@
+package hub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// 按行解析 JSON 日志
+func parseLogs(t *testing.T, b *bytes.Buffer) []map[string]interface{} {
+	var logs []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		m := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("%v: %s", err, line)
+		}
+		logs = append(logs, m)
+	}
+	return logs
+}
+
+// 找到消息为 msg 的日志
+func findLog(logs []map[string]interface{}, msg, key string) map[string]interface{} {
+	for _, m := range logs {
+		if m[key] == msg {
+			return m
+		}
+	}
+	return nil
+}
+
+func Test_GroupLogger(t *testing.T) {
+	crash := func(logger interface{}) {
+		g := NewGroup(GroupName("logger"), GroupLogger(logger), GroupRecovery(1))
+		g.ListenEvent("crash", func(interface{}) { panic("crash") })
+		g.Emit("crash", nil)
+		if err := g.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("slog", func(t *testing.T) {
+		var b bytes.Buffer
+		crash(slog.NewJSONHandler(&b, &slog.HandlerOptions{Level: slog.Level(LevelTrace)}))
+
+		logs := parseLogs(t, &b)
+		m := findLog(logs, "register event handler", "msg")
+		if m == nil || m["group"] != "logger" || m["event"] != "crash" || m["level"] != "DEBUG-4" {
+			t.Fatalf("logs %v", logs)
+		}
+		m = findLog(logs, "panic", "msg")
+		if m == nil || m["group"] != "logger" || m["handler"] != "event:crash" || m["panic"] != "crash" ||
+			!strings.Contains(m["stack"].(string), "Test_GroupLogger") {
+			t.Fatalf("logs %v", logs)
+		}
+	})
+
+	t.Run("zerolog", func(t *testing.T) {
+		var b bytes.Buffer
+		crash(zerolog.New(&b).Level(zerolog.WarnLevel))
+
+		logs := parseLogs(t, &b)
+		if findLog(logs, "register event handler", "message") != nil {
+			t.Fatalf("trace logged %v", logs)
+		}
+		m := findLog(logs, "panic", "message")
+		if m == nil || m["group"] != "logger" || m["level"] != "error" || m["remain"] != float64(0) {
+			t.Fatalf("logs %v", logs)
+		}
+	})
+
+	t.Run("不支持的类型", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("no panic")
+			}
+		}()
+		GroupLogger("stdout")
+	})
+}
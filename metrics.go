@@ -0,0 +1,229 @@
+package hub
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 耗时直方图默认的桶上界
+var DefaultBuckets = []time.Duration{
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// 处理函数的种类
+const (
+	KindProcessor = "processor" // 处理链中的处理器
+	KindEvent     = "event"     // ListenEvent 注册的事件处理函数
+	KindCall      = "call"      // ListenCall 注册的调用处理函数
+	KindSlowCall  = "slowcall"  // SlowCall 的回调
+	KindTimer     = "timer"     // 定时器、计划任务
+)
+
+// 处理函数的标识
+type HandlerKey struct {
+	Kind string // KindProcessor、KindEvent 等
+	Name string // 处理器名称、事件或调用名称
+}
+
+// 耗时分布
+type Histogram struct {
+	Buckets []time.Duration // 各桶的上界
+	Counts  []uint64        // 各桶的数量，不累计，比 Buckets 多一个，最后一个为超出所有上界的数量
+	Count   uint64
+	Sum     time.Duration
+}
+
+func newHistogram() *Histogram {
+	return &Histogram{Buckets: DefaultBuckets, Counts: make([]uint64, len(DefaultBuckets)+1)}
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	i := 0
+	for ; i < len(h.Buckets); i++ {
+		if d <= h.Buckets[i] {
+			break
+		}
+	}
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+}
+
+func (h *Histogram) clone() Histogram {
+	c := *h
+	c.Counts = append([]uint64(nil), h.Counts...)
+	return c
+}
+
+// Hub 运行统计快照
+type HubStats struct {
+	Name       string
+	Working    bool
+	Producers  int    // 监听的通道数量，不含 Emit、Call、定时器等内部通道
+	Received   uint64 // 从监听通道收到的数据数量
+	Panics     uint64 // 处理时发生 panic 的次数
+	Recoveries uint64 // panic 后恢复的次数
+	// 各处理函数的执行时间，Count 即执行次数，批量处理时每批记录一次
+	// 启用 GroupHistograms 时统计，否则为空
+	Handlers map[HandlerKey]Histogram
+}
+
+// Group 运行统计快照
+type GroupStats struct {
+	HubStats
+	Inbox     int       // 收件箱中排队的 Emit、Call、SlowCall 数量
+	InboxCap  int       // 收件箱容量
+	SlowCalls int       // 进行中的 SlowCall 数量
+	QueueWait Histogram // Emit、Call、SlowCall 在收件箱中的等待时间，启用 GroupHistograms 时统计
+}
+
+// 指标接收者，在 group 协程中调用，不应阻塞
+type MetricsSink interface {
+	// 处理函数执行完成
+	ObserveHandler(group string, key HandlerKey, d time.Duration)
+	// Emit、Call、SlowCall 在收件箱中的等待时间
+	ObserveQueueWait(group string, d time.Duration)
+	// 处理时发生 panic，recovered 表示是否恢复
+	ObservePanic(group string, recovered bool)
+}
+
+// hub 的运行统计，计数可在任意协程读取，直方图由 mu 保护
+type hubMetrics struct {
+	producers  int64
+	received   uint64
+	panics     uint64
+	recoveries uint64
+
+	histograms bool // 是否统计耗时分布，不统计且没有 sink 时不计时
+	sink       MetricsSink
+
+	mu       sync.Mutex
+	handlers map[HandlerKey]*Histogram
+	wait     *Histogram
+}
+
+func newHubMetrics(sink MetricsSink, histograms bool) *hubMetrics {
+	return &hubMetrics{handlers: make(map[HandlerKey]*Histogram), wait: newHistogram(), sink: sink, histograms: histograms}
+}
+
+// 记录处理函数 key 从 start 开始的执行时间，只能在 hub 协程中调用
+func (h *Hub) observe(key HandlerKey, start time.Time) {
+	m := h.metrics
+	if !m.histograms && m.sink == nil {
+		return
+	}
+	d := time.Since(start)
+
+	if m.histograms {
+		m.mu.Lock()
+		hist := m.handlers[key]
+		if hist == nil {
+			hist = newHistogram()
+			m.handlers[key] = hist
+		}
+		hist.observe(d)
+		m.mu.Unlock()
+	}
+
+	if m.sink != nil {
+		m.sink.ObserveHandler(h.name, key, d)
+	}
+}
+
+// 记录处理器 p 从 start 开始的执行时间，自行记录的 owner 除外
+// 	启用追踪时记录处理器执行的 span，通道数据没有调用方的 ctx，为根 span
+func (h *Hub) observeProcessor(p IDataProcessor, name string, start time.Time) {
+	if h.owner != nil && p == h.owner {
+		return
+	}
+	h.observe(HandlerKey{Kind: KindProcessor, Name: name}, start)
+	if h.tracer != nil {
+		_, span := h.tracer.Start(context.Background(), SpanProcessorPrefix+name, start, AttrGroup, h.name)
+		span.End(time.Now(), nil)
+	}
+}
+
+// 记录收件箱中的等待时间
+func (h *Hub) observeWait(queued time.Time) {
+	m := h.metrics
+	if !m.histograms && m.sink == nil {
+		return
+	}
+	d := time.Since(queued)
+
+	if m.histograms {
+		m.mu.Lock()
+		m.wait.observe(d)
+		m.mu.Unlock()
+	}
+
+	if m.sink != nil {
+		m.sink.ObserveQueueWait(h.name, d)
+	}
+}
+
+func (h *Hub) observePanic(recovered bool) {
+	atomic.AddUint64(&h.metrics.panics, 1)
+	if recovered {
+		atomic.AddUint64(&h.metrics.recoveries, 1)
+	}
+	if h.metrics.sink != nil {
+		h.metrics.sink.ObservePanic(h.name, recovered)
+	}
+}
+
+// 监听通道数量变化，内部控制通道不计入
+func (h *Hub) countProducer(info *producerInfo, delta int64) {
+	if info.level != priorityControl {
+		atomic.AddInt64(&h.metrics.producers, delta)
+	}
+}
+
+// 运行统计快照，可在任意协程中调用
+func (h *Hub) Stats() HubStats {
+	m := h.metrics
+	stats := HubStats{
+		Name:       h.name,
+		Working:    h.IsWorking(),
+		Producers:  int(atomic.LoadInt64(&m.producers)),
+		Received:   atomic.LoadUint64(&m.received),
+		Panics:     atomic.LoadUint64(&m.panics),
+		Recoveries: atomic.LoadUint64(&m.recoveries),
+	}
+
+	m.mu.Lock()
+	stats.Handlers = make(map[HandlerKey]Histogram, len(m.handlers))
+	for k, hist := range m.handlers {
+		stats.Handlers[k] = hist.clone()
+	}
+	m.mu.Unlock()
+	return stats
+}
+
+// 运行统计快照，可在任意协程中调用，不会等待 group 协程
+func (g *Group) Stats() GroupStats {
+	stats := GroupStats{
+		HubStats:  g.hub.Stats(),
+		Inbox:     len(g.processChan),
+		InboxCap:  cap(g.processChan),
+		SlowCalls: g.slowCalls.count(),
+	}
+
+	m := g.hub.metrics
+	m.mu.Lock()
+	stats.QueueWait = m.wait.clone()
+	m.mu.Unlock()
+	return stats
+}
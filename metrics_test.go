@@ -0,0 +1,105 @@
+package hub
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_Stats(t *testing.T) {
+	for _, e := range engines {
+		t.Run(e.name, func(t *testing.T) {
+			p := &tOrderProcessor{}
+			g := NewGroup(GroupName("stats"), GroupEngine(e.engine), GroupHandles(p), GroupRecovery(1),
+				GroupPanicHandler(func(PanicInfo) bool { return true }), GroupHistograms())
+			g.ListenEvent("login", func(interface{}) {})
+			g.ListenEvent("crash", func(interface{}) { panic("crash") })
+			g.ListenCall("query", func(arg interface{}) Return { return Return{Value: arg} })
+
+			ch := make(chan interface{})
+			g.Attach(ch)
+			ch <- 1
+			ch <- 2
+			g.Emit("login", nil)
+			g.Emit("crash", nil)
+			g.Call("query", nil)
+
+			st := g.Stats()
+			if st.Name != "stats" || !st.Working || st.Producers != 1 || st.Received != 2 ||
+				st.Panics != 1 || st.Recoveries != 1 || st.InboxCap != groupChanLen {
+				t.Fatalf("stats %+v", st)
+			}
+			for key, n := range map[HandlerKey]uint64{
+				{KindProcessor, "tOrderProcessor"}: 2,
+				{KindEvent, "login"}:               1,
+				{KindCall, "query"}:                1,
+			} {
+				if h := st.Handlers[key]; h.Count != n || len(h.Counts) != len(h.Buckets)+1 {
+					t.Fatalf("%v %+v", key, h)
+				}
+			}
+			// Group 自身不计入处理器
+			if _, ok := st.Handlers[HandlerKey{KindProcessor, "stats"}]; ok {
+				t.Fatalf("handlers %+v", st.Handlers)
+			}
+			if st.QueueWait.Count != 3 {
+				t.Fatalf("queue wait %+v", st.QueueWait)
+			}
+
+			g.Detach(ch)
+			if err := g.Shutdown(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if st := g.Stats(); st.Producers != 0 || st.Working {
+				t.Fatalf("stats %+v", st)
+			}
+		})
+	}
+}
+
+func Test_StatsWithoutHistograms(t *testing.T) {
+	g := NewGroup()
+	g.ListenCall("query", func(arg interface{}) Return { return Return{Value: arg} })
+	g.Call("query", nil)
+
+	st := g.Stats()
+	if len(st.Handlers) != 0 || st.QueueWait.Count != 0 {
+		t.Fatalf("stats %+v", st)
+	}
+	if err := g.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_PrometheusSink(t *testing.T) {
+	sink := NewPrometheusSink()
+	g := NewGroup(GroupName(`a"b`), GroupMetrics(sink))
+	sink.Watch(g)
+	g.ListenCall("query", func(arg interface{}) Return { return Return{} })
+	g.Call("query", nil)
+
+	w := httptest.NewRecorder()
+	sink.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+	for _, line := range []string{
+		`# TYPE hub_handler_duration_seconds histogram`,
+		`hub_handler_duration_seconds_bucket{group="a\"b",kind="call",name="query",le="+Inf"} 1`,
+		`hub_handler_duration_seconds_count{group="a\"b",kind="call",name="query"} 1`,
+		`hub_queue_wait_seconds_count{group="a\"b"} 1`,
+		`hub_inbox_capacity{group="a\"b"} 12`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Fatalf("missing %q in\n%s", line, body)
+		}
+	}
+
+	// 退出的 Group 不再输出
+	g.Stop()
+	<-g.Done()
+	var b strings.Builder
+	sink.WriteTo(&b)
+	if strings.Contains(b.String(), "hub_inbox_capacity{") {
+		t.Fatalf("stopped group in\n%s", b.String())
+	}
+}
@@ -0,0 +1,258 @@
+package hub
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// 缓冲已满时的处理策略
+type OverflowPolicy int
+
+const (
+	// 拒绝新数据，返回 ErrOverflow
+	OverflowReject OverflowPolicy = iota
+	// 丢弃新数据，作为死信报告，返回 ErrOverflow
+	OverflowDropNewest
+	// 丢弃最早缓冲的数据，作为死信报告，再缓冲新数据
+	OverflowDropOldest
+	// 阻塞等待，直到有空间
+	OverflowBlock
+	// 阻塞等待，超时后返回 ErrOverflow
+	OverflowBlockTimeout
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowReject:
+		return "reject"
+	case OverflowDropNewest:
+		return "drop newest"
+	case OverflowDropOldest:
+		return "drop oldest"
+	case OverflowBlock:
+		return "block"
+	case OverflowBlockTimeout:
+		return "block timeout"
+	}
+	return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+}
+
+// 默认每个目标通道最多缓冲的数据数量
+const defaultSendLimit = 1024
+
+// SendTo 的发送缓冲，只在 hub 协程中访问
+//
+// 有待发送数据时，才把目标通道的 SelectSend 加入 select
+type outbox struct {
+	limit   int // 每个目标通道最多缓冲的数据数量，<=0 不限制
+	policy  OverflowPolicy
+	queues  []*outQueue // 有待发送数据的目标通道，按开始缓冲的顺序
+	cases   []reflect.SelectCase
+	flushed chan struct{} // 缓冲清空时关闭
+}
+
+type outQueue struct {
+	ch    reflect.Value
+	items []reflect.Value
+}
+
+func (o *outbox) pending() bool {
+	return len(o.queues) > 0
+}
+
+// 在 cases 之后追加各目标通道的 SelectSend，返回的切片在下次调用前有效
+func (o *outbox) with(cases []reflect.SelectCase) []reflect.SelectCase {
+	o.cases = append(o.cases[:0], cases...)
+	for _, q := range o.queues {
+		o.cases = append(o.cases, reflect.SelectCase{Dir: reflect.SelectSend, Chan: q.ch, Send: q.items[0]})
+	}
+	return o.cases
+}
+
+// 第 i 个目标通道已发送队首数据
+func (o *outbox) sent(i int) {
+	q := o.queues[i]
+	q.items[0] = reflect.Value{}
+	q.items = q.items[1:]
+	if len(q.items) == 0 {
+		o.remove(i)
+	}
+}
+
+// 移除第 i 个目标通道，返回其中剩余的数据
+func (o *outbox) remove(i int) []reflect.Value {
+	items := o.queues[i].items
+	o.queues = append(o.queues[:i], o.queues[i+1:]...)
+	if len(o.queues) == 0 && o.flushed != nil {
+		close(o.flushed)
+		o.flushed = nil
+	}
+	return items
+}
+
+// 缓冲清空时关闭的通道
+func (o *outbox) wait() <-chan struct{} {
+	if o.flushed == nil {
+		o.flushed = make(chan struct{})
+		if !o.pending() {
+			ch := o.flushed
+			close(ch)
+			o.flushed = nil
+			return ch
+		}
+	}
+	return o.flushed
+}
+
+func (o *outbox) queue(ch reflect.Value) *outQueue {
+	for _, q := range o.queues {
+		if q.ch.Pointer() == ch.Pointer() {
+			return q
+		}
+	}
+	return nil
+}
+
+// 发送 v 到通道 ch，ch 暂时不可写时缓冲，只能在 hub 协程中调用
+func (h *Hub) sendTo(ch, v interface{}) error {
+	c := reflect.ValueOf(ch)
+	if ch == nil || c.Kind() == reflect.Chan && c.IsNil() {
+		// 向 nil 通道发送永远阻塞，缓冲的数据会一直等到 Shutdown 超时
+		return ErrNilChan
+	}
+	if c.Kind() != reflect.Chan || c.Type().ChanDir()&reflect.SendDir == 0 {
+		panic(fmt.Sprintf("hub: SendTo requires a sendable channel, got %T", ch))
+	}
+
+	elem := c.Type().Elem()
+	var value reflect.Value
+	switch {
+	case v != nil && reflect.TypeOf(v).AssignableTo(elem):
+		value = reflect.ValueOf(v).Convert(elem)
+	case v == nil && nilable(elem):
+		value = reflect.Zero(elem)
+	default:
+		return fmt.Errorf("%w: SendTo want %v, got %T", ErrTypeMismatch, elem, v)
+	}
+
+	q := h.out.queue(c)
+	if q == nil {
+		// 没有缓冲的数据，直接尝试发送
+		sent, closed := trySend(c, value)
+		if closed {
+			return ErrChanClosed
+		}
+		if sent {
+			return nil
+		}
+		q = &outQueue{ch: c}
+		h.out.queues = append(h.out.queues, q)
+	}
+
+	if h.out.limit > 0 && len(q.items) >= h.out.limit {
+		switch h.out.policy {
+		case OverflowDropNewest:
+			h.dropped(ch, v)
+			return fmt.Errorf("%w: SendTo %d items pending", ErrOverflow, len(q.items))
+		case OverflowDropOldest:
+			oldest := q.items[0]
+			q.items[0] = reflect.Value{}
+			q.items = q.items[1:]
+			h.dropped(ch, oldest.Interface())
+		default:
+			return fmt.Errorf("%w: SendTo %d items pending", ErrOverflow, len(q.items))
+		}
+	}
+
+	q.items = append(q.items, value)
+	return nil
+}
+
+// 尝试发送，通道已关闭时 closed 为 true
+func trySend(ch, v reflect.Value) (sent, closed bool) {
+	defer func() {
+		if recover() != nil {
+			closed = true
+		}
+	}()
+	return ch.TrySend(v), false
+}
+
+// 同时等待 cases 和各目标通道可写，chosen >= len(cases) 时第 chosen-len(cases) 个目标通道已发送
+// 	目标通道已关闭时 select 会 panic，此时移除已关闭通道的缓冲，作为死信报告，chosen 为 -1
+func (h *Hub) selectOut(cases []reflect.SelectCase) (chosen int, recv reflect.Value, recvOK bool) {
+	defer func() {
+		if recover() != nil {
+			h.dropClosed()
+			chosen, recv, recvOK = -1, reflect.Value{}, false
+		}
+	}()
+	return reflect.Select(h.out.with(cases))
+}
+
+// 逐个尝试发送各目标通道的队首数据，移除已关闭的目标通道
+func (h *Hub) dropClosed() {
+	for i := len(h.out.queues) - 1; i >= 0; i-- {
+		q := h.out.queues[i]
+		sent, closed := trySend(q.ch, q.items[0])
+		switch {
+		case closed:
+			for _, item := range h.out.remove(i) {
+				h.dropped(q.ch.Interface(), item.Interface())
+			}
+		case sent:
+			h.out.sent(i)
+		}
+	}
+}
+
+func (h *Hub) dropped(ch, data interface{}) {
+	if h.onDead != nil {
+		h.onDead(DeadOverflow, "SendTo", ch, data)
+	}
+}
+
+// 取出发送缓冲中剩余的数据，作为死信报告，只能在 hub 协程中调用
+func (h *Hub) discardOutbox() {
+	for _, q := range h.out.queues {
+		for _, item := range q.items {
+			h.onDead(DeadGroupStopped, "SendTo", q.ch.Interface(), item.Interface())
+		}
+	}
+	h.out.queues = nil
+}
+
+// 发送 v 到通道 ch，只能在 group 协程中调用，如处理器、事件和调用处理函数中
+// 	ch 暂时不可写时缓冲，不阻塞 group 协程，ch 可写后依次发送
+// 	同一通道的数据按调用顺序发送
+// 	每个通道的缓冲已满时，按 GroupSendBuffer 指定的策略处理
+// 	ch 已关闭时返回 ErrChanClosed；缓冲期间 ch 被关闭，剩余的数据作为 DeadOverflow 死信报告
+// 	ch 为 nil 时返回 ErrNilChan；v 的类型与通道元素类型不符时返回 ErrTypeMismatch
+// 	Shutdown 等待缓冲的数据发送完，没有人接收的 ch 会使 Shutdown 一直等到 ctx 结束
+// 	在 group 协程外调用时返回 ErrNotInGroup，不发送
+func (g *Group) SendTo(ch, v interface{}) error {
+	if !g.hub.inHub() {
+		return ErrNotInGroup
+	}
+	return g.hub.sendTo(ch, v)
+}
+
+// 等待 SendTo 缓冲的数据发送完
+func (g *Group) waitFlushed(ctx context.Context) error {
+	var flushed <-chan struct{}
+	if err := g.runInGroup(ctx, func() {
+		flushed = g.hub.out.wait()
+	}); err != nil {
+		return err
+	}
+
+	select {
+	case <-flushed:
+		return nil
+	case <-g.hub.done:
+		return ErrGroupStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,229 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// 把收到的数据用 SendTo 转发到 out
+type tForwardProcessor struct {
+	g   *Group
+	out chan int
+}
+
+func (*tForwardProcessor) Name() string {
+	return "tForwardProcessor"
+}
+
+func (p *tForwardProcessor) OnData(data interface{}) interface{} {
+	if err := p.g.SendTo(p.out, data); err != nil {
+		return data
+	}
+	return nil
+}
+
+func Test_SendTo(t *testing.T) {
+	for _, e := range engines {
+		t.Run(e.name, func(t *testing.T) {
+			t.Run("不阻塞且保持顺序", func(t *testing.T) {
+				p := &tForwardProcessor{out: make(chan int)}
+				g := NewGroup(GroupEngine(e.engine), GroupHandles(p))
+				p.g = g
+				in := make(chan int)
+				g.Attach(in)
+
+				// 没有人接收 out 时，group 协程仍在处理 in
+				for i := 0; i < 100; i++ {
+					in <- i
+				}
+				for i := 0; i < 100; i++ {
+					select {
+					case v := <-p.out:
+						if v != i {
+							t.Fatalf("got %d want %d", v, i)
+						}
+					case <-time.After(time.Second):
+						t.Fatalf("timeout at %d", i)
+					}
+				}
+				g.Stop()
+			})
+
+			t.Run("Shutdown等待发送完", func(t *testing.T) {
+				p := &tForwardProcessor{out: make(chan int)}
+				g := NewGroup(GroupEngine(e.engine), GroupHandles(p))
+				p.g = g
+				in := make(chan int)
+				g.Attach(in)
+				in <- 1
+				in <- 2
+
+				got := make(chan []int)
+				go func() {
+					time.Sleep(20 * time.Millisecond)
+					got <- []int{<-p.out, <-p.out}
+				}()
+				if err := g.Shutdown(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+				if v := <-got; v[0] != 1 || v[1] != 2 {
+					t.Fatalf("got %v", v)
+				}
+			})
+		})
+	}
+
+	t.Run("目标通道已关闭", func(t *testing.T) {
+		for _, e := range engines {
+			t.Run(e.name, func(t *testing.T) {
+				ring := NewDeadLetterRing(8)
+				g := NewGroup(GroupEngine(e.engine), GroupDeadLetter(ring))
+				defer g.Stop()
+				out := make(chan int)
+				g.runInGroup(context.Background(), func() {
+					for i := 1; i <= 3; i++ {
+						g.SendTo(out, i)
+					}
+				})
+				// 缓冲期间关闭，group 协程不应 panic
+				g.runInGroup(context.Background(), func() { close(out) })
+
+				deadline := time.Now().Add(time.Second)
+				for len(ring.Letters()) < 3 && time.Now().Before(deadline) {
+					time.Sleep(time.Millisecond)
+				}
+				letters := ring.Letters()
+				if len(letters) != 3 {
+					t.Fatalf("letters %+v", letters)
+				}
+				for i, l := range letters {
+					if l.Reason != DeadOverflow || l.Name != "SendTo" || l.Data != i+1 {
+						t.Fatalf("letter %+v", l)
+					}
+				}
+
+				var err error
+				g.runInGroup(context.Background(), func() {
+					err = g.SendTo(out, 4)
+				})
+				if !errors.Is(err, ErrChanClosed) {
+					t.Fatalf("want ErrChanClosed, got %v", err)
+				}
+				if err := g.Shutdown(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+	})
+
+	t.Run("缓冲已满", func(t *testing.T) {
+		cases := []struct {
+			policy OverflowPolicy
+			errs   int
+			dead   []interface{}
+			recv   []int
+		}{
+			{OverflowReject, 1, nil, []int{1, 2}},
+			{OverflowDropNewest, 1, []interface{}{3}, []int{1, 2}},
+			{OverflowDropOldest, 0, []interface{}{1}, []int{2, 3}},
+		}
+		for _, c := range cases {
+			t.Run(c.policy.String(), func(t *testing.T) {
+				ring := NewDeadLetterRing(8)
+				g := NewGroup(GroupSendBuffer(2, c.policy), GroupDeadLetter(ring))
+				out := make(chan int)
+				errs := 0
+				g.runInGroup(context.Background(), func() {
+					for i := 1; i <= 3; i++ {
+						if err := g.SendTo(out, i); errors.Is(err, ErrOverflow) {
+							errs++
+						}
+					}
+				})
+				if errs != c.errs {
+					t.Fatalf("errs %d", errs)
+				}
+
+				letters := ring.Letters()
+				if len(letters) != len(c.dead) {
+					t.Fatalf("letters %+v", letters)
+				}
+				for i, l := range letters {
+					if l.Reason != DeadOverflow || l.Name != "SendTo" || l.Data != c.dead[i] || l.Producer != out {
+						t.Fatalf("letter %+v", l)
+					}
+				}
+
+				for _, want := range c.recv {
+					if v := <-out; v != want {
+						t.Fatalf("got %d want %d", v, want)
+					}
+				}
+				g.Stop()
+			})
+		}
+	})
+
+	t.Run("类型不符", func(t *testing.T) {
+		g := NewGroup()
+		defer g.Stop()
+
+		var err error
+		g.runInGroup(context.Background(), func() {
+			err = g.SendTo(make(chan int, 1), "1")
+		})
+		if !errors.Is(err, ErrTypeMismatch) {
+			t.Fatalf("want ErrTypeMismatch, got %v", err)
+		}
+	})
+
+	t.Run("group协程外调用", func(t *testing.T) {
+		g := NewGroup()
+		defer g.Stop()
+
+		out := make(chan int, 1)
+		if err := g.SendTo(out, 1); !errors.Is(err, ErrNotInGroup) {
+			t.Fatalf("want ErrNotInGroup, got %v", err)
+		}
+		if len(out) != 0 {
+			t.Fatal("sent outside the group goroutine")
+		}
+	})
+
+	t.Run("nil通道", func(t *testing.T) {
+		g := NewGroup()
+		defer g.Stop()
+
+		var errs []error
+		g.runInGroup(context.Background(), func() {
+			var out chan int
+			errs = append(errs, g.SendTo(out, 1), g.SendTo(nil, 1))
+		})
+		for _, err := range errs {
+			if !errors.Is(err, ErrNilChan) {
+				t.Fatalf("want ErrNilChan, got %v", err)
+			}
+		}
+		if err := g.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("停止时剩余数据作为死信", func(t *testing.T) {
+		ring := NewDeadLetterRing(8)
+		g := NewGroup(GroupDeadLetter(ring))
+		out := make(chan string)
+		g.runInGroup(context.Background(), func() {
+			g.SendTo(out, "lost")
+		})
+		g.Stop()
+		<-g.Done()
+
+		letters := ring.Letters()
+		if len(letters) != 1 || letters[0].Reason != DeadGroupStopped || letters[0].Data != "lost" {
+			t.Fatalf("letters %+v", letters)
+		}
+	})
+}
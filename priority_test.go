@@ -0,0 +1,92 @@
+package hub
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 记录处理顺序
+type tOrderProcessor struct {
+	mu    sync.Mutex
+	order []interface{}
+}
+
+func (*tOrderProcessor) Name() string {
+	return "tOrderProcessor"
+}
+
+func (p *tOrderProcessor) OnData(data interface{}) interface{} {
+	p.mu.Lock()
+	p.order = append(p.order, data)
+	p.mu.Unlock()
+	return nil
+}
+
+func Test_Priority(t *testing.T) {
+	t.Run("高优先级先处理", func(t *testing.T) {
+		p := &tOrderProcessor{}
+		g := NewGroup(GroupHandles(p))
+		low, high := make(chan interface{}, 3), make(chan interface{}, 3)
+		g.AttachPriority(low, PriorityLow)
+		g.AttachPriority(high, PriorityHigh)
+
+		started, block := make(chan struct{}), make(chan struct{})
+		g.ListenEvent("block", func(interface{}) {
+			close(started)
+			<-block
+		})
+		g.Emit("block", nil)
+		<-started
+		for i := 0; i < 3; i++ {
+			low <- "low"
+			high <- "high"
+		}
+		close(block)
+		if err := g.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		want := []interface{}{"high", "high", "high", "low", "low", "low"}
+		if len(p.order) != len(want) {
+			t.Fatalf("order %v", p.order)
+		}
+		for i := range want {
+			if p.order[i] != want[i] {
+				t.Fatalf("order %v", p.order)
+			}
+		}
+	})
+
+	for _, e := range engines {
+		t.Run(e.name+"/控制通道不受数据洪泛影响", func(t *testing.T) {
+			g := NewGroup(GroupEngine(e.engine), GroupHandles(&tOrderProcessor{}))
+			g.ListenCall("ping", func(interface{}) Return { return Return{Value: "pong"} })
+
+			flood := make(chan interface{}, 64)
+			g.Attach(flood)
+			stop := make(chan struct{})
+			defer close(stop)
+			go func() {
+				for {
+					select {
+					case flood <- 1:
+					case <-stop:
+						return
+					}
+				}
+			}()
+
+			for i := 0; i < 100; i++ {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				ret, _ := g.CallContext(ctx, "ping", nil)
+				cancel()
+				if ret.Value != "pong" {
+					t.Fatalf("call %d: %v", i, ret.Error)
+				}
+			}
+			g.Stop()
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package hub
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prometheus 文本格式的指标，实现 MetricsSink 和 http.Handler
+//
+// 处理函数执行时间、收件箱等待时间和 panic 由 GroupMetrics 报告；
+// Watch 的 Group 在抓取时读取 Stats，输出收件箱长度、通道数量等
+type PrometheusSink struct {
+	mu       sync.Mutex
+	handlers map[promHandler]*Histogram
+	waits    map[string]*Histogram
+	panics   map[promPanic]uint64
+	groups   []*Group
+}
+
+type promHandler struct {
+	group string
+	key   HandlerKey
+}
+
+type promPanic struct {
+	group     string
+	recovered bool
+}
+
+// 构建 Prometheus 指标
+func NewPrometheusSink() *PrometheusSink {
+	return &PrometheusSink{
+		handlers: make(map[promHandler]*Histogram),
+		waits:    make(map[string]*Histogram),
+		panics:   make(map[promPanic]uint64),
+	}
+}
+
+func (p *PrometheusSink) ObserveHandler(group string, key HandlerKey, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	k := promHandler{group, key}
+	hist := p.handlers[k]
+	if hist == nil {
+		hist = newHistogram()
+		p.handlers[k] = hist
+	}
+	hist.observe(d)
+}
+
+func (p *PrometheusSink) ObserveQueueWait(group string, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	hist := p.waits[group]
+	if hist == nil {
+		hist = newHistogram()
+		p.waits[group] = hist
+	}
+	hist.observe(d)
+}
+
+func (p *PrometheusSink) ObservePanic(group string, recovered bool) {
+	p.mu.Lock()
+	p.panics[promPanic{group, recovered}]++
+	p.mu.Unlock()
+}
+
+// 抓取时输出 g 的收件箱长度、通道数量、进行中的 SlowCall 等，g 退出后不再输出
+func (p *PrometheusSink) Watch(g *Group) {
+	p.mu.Lock()
+	p.groups = append(p.groups, g)
+	p.mu.Unlock()
+}
+
+// 输出 Prometheus 文本格式的指标
+func (p *PrometheusSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.WriteTo(w)
+}
+
+// 以 Prometheus 文本格式写入 w
+func (p *PrometheusSink) WriteTo(w io.Writer) (int64, error) {
+	buf := bufio.NewWriter(w)
+	bw := &countWriter{w: buf}
+
+	p.mu.Lock()
+	groups := p.groups[:0]
+	for _, g := range p.groups {
+		select {
+		case <-g.Done():
+		default:
+			groups = append(groups, g)
+		}
+	}
+	for i := len(groups); i < len(p.groups); i++ {
+		p.groups[i] = nil
+	}
+	p.groups = groups
+	groups = append([]*Group(nil), groups...)
+
+	handlers := make([]promHandler, 0, len(p.handlers))
+	for k := range p.handlers {
+		handlers = append(handlers, k)
+	}
+	sort.Slice(handlers, func(i, j int) bool {
+		a, b := handlers[i], handlers[j]
+		if a.group != b.group {
+			return a.group < b.group
+		}
+		if a.key.Kind != b.key.Kind {
+			return a.key.Kind < b.key.Kind
+		}
+		return a.key.Name < b.key.Name
+	})
+	writeHeader(bw, "hub_handler_duration_seconds", "histogram", "Handler execution time on the group goroutine.")
+	for _, k := range handlers {
+		writeHistogram(bw, "hub_handler_duration_seconds", labels("group", k.group, "kind", k.key.Kind, "name", k.key.Name), p.handlers[k])
+	}
+
+	waits := make([]string, 0, len(p.waits))
+	for group := range p.waits {
+		waits = append(waits, group)
+	}
+	sort.Strings(waits)
+	writeHeader(bw, "hub_queue_wait_seconds", "histogram", "Time Emit, Call and SlowCall spend in the inbox.")
+	for _, group := range waits {
+		writeHistogram(bw, "hub_queue_wait_seconds", labels("group", group), p.waits[group])
+	}
+
+	panics := make([]promPanic, 0, len(p.panics))
+	for k := range p.panics {
+		panics = append(panics, k)
+	}
+	sort.Slice(panics, func(i, j int) bool {
+		if panics[i].group != panics[j].group {
+			return panics[i].group < panics[j].group
+		}
+		return !panics[i].recovered && panics[j].recovered
+	})
+	writeHeader(bw, "hub_panics_total", "counter", "Panics while processing.")
+	for _, k := range panics {
+		fmt.Fprintf(bw, "hub_panics_total{%s} %d\n", labels("group", k.group, "recovered", strconv.FormatBool(k.recovered)), p.panics[k])
+	}
+	p.mu.Unlock()
+
+	// 不持有锁读取 Stats
+	stats := make([]GroupStats, len(groups))
+	for i, g := range groups {
+		stats[i] = g.Stats()
+	}
+	gauges := []struct {
+		name, typ, help string
+		value           func(st *GroupStats) uint64
+	}{
+		{"hub_inbox_length", "gauge", "Emit, Call and SlowCall queued in the inbox.", func(st *GroupStats) uint64 { return uint64(st.Inbox) }},
+		{"hub_inbox_capacity", "gauge", "Inbox capacity.", func(st *GroupStats) uint64 { return uint64(st.InboxCap) }},
+		{"hub_producers", "gauge", "Attached producer channels.", func(st *GroupStats) uint64 { return uint64(st.Producers) }},
+		{"hub_slow_calls", "gauge", "SlowCalls in progress.", func(st *GroupStats) uint64 { return uint64(st.SlowCalls) }},
+		{"hub_received_total", "counter", "Values received from producer channels.", func(st *GroupStats) uint64 { return st.Received }},
+		{"hub_recoveries_total", "counter", "Recoveries after panic.", func(st *GroupStats) uint64 { return st.Recoveries }},
+	}
+	for _, gauge := range gauges {
+		writeHeader(bw, gauge.name, gauge.typ, gauge.help)
+		for i := range stats {
+			fmt.Fprintf(bw, "%s{%s} %d\n", gauge.name, labels("group", stats[i].Name), gauge.value(&stats[i]))
+		}
+	}
+
+	return bw.n, buf.Flush()
+}
+
+func writeHeader(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// 按 Prometheus 直方图格式输出，桶的数量累计
+func writeHistogram(w io.Writer, name, lbs string, hist *Histogram) {
+	var cumulative uint64
+	for i, le := range hist.Buckets {
+		cumulative += hist.Counts[i]
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, lbs, seconds(le), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, lbs, hist.Count)
+	fmt.Fprintf(w, "%s_sum{%s} %s\n", name, lbs, seconds(hist.Sum))
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, lbs, hist.Count)
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// 按 name、value 成对输出标签
+func labels(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(pairs[i+1]))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+// 记录写入的字节数
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,207 @@
+package hub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// 优雅停止的步骤
+type ShutdownStep int
+
+const (
+	ShutdownReject ShutdownStep = iota + 1 // 停止接收新的 Emit、Call、SlowCall、定时器
+	ShutdownDrain                          // 处理生产者通道中已排队的数据，等待 SendTo 缓冲的数据发送完
+	ShutdownWait                           // 取消定时器，等待进行中的 SlowCall 完成
+	ShutdownHooks                          // 在 group 协程中执行停止钩子
+	ShutdownExit                           // 等待 group 协程退出
+)
+
+func (s ShutdownStep) String() string {
+	switch s {
+	case ShutdownReject:
+		return "reject"
+	case ShutdownDrain:
+		return "drain"
+	case ShutdownWait:
+		return "wait"
+	case ShutdownHooks:
+		return "hooks"
+	case ShutdownExit:
+		return "exit"
+	}
+	return fmt.Sprintf("ShutdownStep(%d)", int(s))
+}
+
+// 优雅停止在某一步骤失败
+type ShutdownError struct {
+	Step ShutdownStep
+	Err  error
+}
+
+func (e *ShutdownError) Error() string {
+	return "hub: shutdown " + e.Step.String() + ": " + e.Err.Error()
+}
+
+func (e *ShutdownError) Unwrap() error {
+	return e.Err
+}
+
+// 注册停止钩子，Shutdown 时按注册顺序在 group 协程中执行
+func (g *Group) OnStop(hook func()) {
+	g.hooksMu.Lock()
+	g.stopHooks = append(g.stopHooks, hook)
+	g.hooksMu.Unlock()
+}
+
+// 优雅停止，按步骤执行：
+// 	停止接收新的 Emit、Call、SlowCall 和定时器；
+// 	处理生产者通道中已排队的数据，等待 SendTo 缓冲的数据发送完；
+// 	取消定时器，等待进行中的 SlowCall 完成；
+// 	在 group 协程中执行停止钩子；
+// 	等待 group 协程退出后返回。
+//
+// ctx 结束时放弃剩余步骤，立即停止，返回 *ShutdownError
+//
+// 不可在 group 协程中调用，否则会等待自身，此时返回 ErrInGroup，不停止；
+// 处理函数中需要停止时，调用 Stop 或在新协程中调用 Shutdown
+func (g *Group) Shutdown(ctx context.Context) error {
+	if g.hub.inHub() {
+		return ErrInGroup
+	}
+	if !atomic.CompareAndSwapInt32(&g.closing, 0, 1) {
+		// 已在停止中，等待退出
+		select {
+		case <-g.hub.done:
+			return nil
+		case <-ctx.Done():
+			return &ShutdownError{Step: ShutdownExit, Err: ctx.Err()}
+		}
+	}
+	g.report(ShutdownReject, nil)
+
+	steps := []struct {
+		step ShutdownStep
+		run  func() error
+	}{
+		{ShutdownDrain, func() error {
+			if err := g.runInGroup(ctx, g.hub.drain); err != nil {
+				return err
+			}
+			return g.waitFlushed(ctx)
+		}},
+		{ShutdownWait, func() error {
+			g.timers.stop()
+			select {
+			case <-g.slowCalls.wait():
+				return nil
+			case <-g.hub.done:
+				return ErrGroupStopped
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}},
+		{ShutdownHooks, func() error {
+			return g.runInGroup(ctx, g.runStopHooks)
+		}},
+		{ShutdownExit, func() error {
+			g.hub.Stop()
+			select {
+			case <-g.hub.done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}},
+	}
+
+	for _, s := range steps {
+		err := s.run()
+		g.report(s.step, err)
+		if err != nil {
+			se := &ShutdownError{Step: s.step, Err: err}
+			g.stop(se)
+			return se
+		}
+	}
+	return nil
+}
+
+func (g *Group) report(step ShutdownStep, err error) {
+	g.hub.log.debug("shutdown", "step", step.String(), "error", err)
+	if g.config.ShutdownReport != nil {
+		g.config.ShutdownReport(step, err)
+	}
+}
+
+// 在 group 协程中执行 fn，并等待完成
+func (g *Group) runInGroup(ctx context.Context, fn func()) error {
+	done := make(chan struct{})
+	if !g.hub.exec(func() {
+		defer close(done)
+		fn()
+	}) {
+		return ErrGroupStopped
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-g.hub.done:
+		return ErrGroupStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (g *Group) runStopHooks() {
+	g.hooksMu.Lock()
+	hooks := g.stopHooks
+	g.hooksMu.Unlock()
+
+	for _, hook := range hooks {
+		hook()
+	}
+}
+
+// 进行中的任务计数，计数归零时通知等待方
+type pendingCounter struct {
+	mu   sync.Mutex
+	n    int
+	zero chan struct{}
+}
+
+func (p *pendingCounter) add(delta int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.n += delta
+	if p.n == 0 && p.zero != nil {
+		close(p.zero)
+		p.zero = nil
+	}
+}
+
+func (p *pendingCounter) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.n
+}
+
+// 计数归零时关闭的通道
+func (p *pendingCounter) wait() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.zero == nil {
+		p.zero = make(chan struct{})
+		if p.n == 0 {
+			close(p.zero)
+			ch := p.zero
+			p.zero = nil
+			return ch
+		}
+	}
+	return p.zero
+}
@@ -0,0 +1,409 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// 重启策略
+type RestartStrategy int
+
+const (
+	// 只重启异常退出的 Group
+	OneForOne RestartStrategy = iota
+	// 一个 Group 异常退出，停止并重启所有 Group
+	OneForAll
+	// 一个 Group 异常退出，停止并重启它和排在它之后的 Group
+	RestForOne
+)
+
+// 被监管的 Group 规格
+type ChildSpec struct {
+	Name    string
+	Options []GroupOption
+	// 创建和每次重启后调用，用于重建 Group 的状态，如注册事件、附加通道
+	// 返回错误视同异常退出
+	Init func(g *Group) error
+}
+
+type supervisorConfig struct {
+	Name            string
+	Strategy        RestartStrategy
+	MaxRestarts     int           // Period 内最多重启次数，超出后监管者停止
+	Period          time.Duration // 重启次数的统计窗口
+	BackoffMin      time.Duration // 重启前的等待时间，连续重启时指数增长
+	BackoffMax      time.Duration
+	ShutdownTimeout time.Duration // 停止 Group 的超时时间
+	Logger          Logger
+	Clock           Clock // 重启强度统计和重启等待使用的时钟
+}
+
+type SupervisorOption func(sc *supervisorConfig)
+
+// 监管者名称
+func SupervisorName(name string) func(sc *supervisorConfig) {
+	return func(sc *supervisorConfig) {
+		sc.Name = name
+	}
+}
+
+// 重启策略，默认 OneForOne
+func SupervisorStrategy(strategy RestartStrategy) func(sc *supervisorConfig) {
+	return func(sc *supervisorConfig) {
+		sc.Strategy = strategy
+	}
+}
+
+// 重启强度，period 内重启超过 maxRestarts 次，停止所有 Group 和监管者
+// 默认5秒内最多3次
+func SupervisorIntensity(maxRestarts int, period time.Duration) func(sc *supervisorConfig) {
+	return func(sc *supervisorConfig) {
+		sc.MaxRestarts = maxRestarts
+		sc.Period = period
+	}
+}
+
+// 重启等待时间，从 min 开始，统计窗口内每多重启一次翻倍，最长 max，max <= 0 时不限
+// 默认不等待
+func SupervisorBackoff(min, max time.Duration) func(sc *supervisorConfig) {
+	return func(sc *supervisorConfig) {
+		sc.BackoffMin = min
+		sc.BackoffMax = max
+	}
+}
+
+// 停止单个 Group 的超时时间，超时后强制停止
+// 默认5秒
+func SupervisorShutdownTimeout(timeout time.Duration) func(sc *supervisorConfig) {
+	return func(sc *supervisorConfig) {
+		sc.ShutdownTimeout = timeout
+	}
+}
+
+// 监管者的日志输出，参数同 GroupLogger
+// 被监管的 Group 在 ChildSpec.Options 中指定
+func SupervisorLogger(logger interface{}) func(sc *supervisorConfig) {
+	l := toLogger(logger)
+	return func(sc *supervisorConfig) {
+		sc.Logger = l
+	}
+}
+
+// 监管者的时钟，用于重启强度统计和重启等待，测试时可替换为 hubtest.FakeClock
+// 被监管的 Group 在 ChildSpec.Options 中指定
+func SupervisorClock(clock Clock) func(sc *supervisorConfig) {
+	return func(sc *supervisorConfig) {
+		sc.Clock = clock
+	}
+}
+
+// 监管者，Group 异常退出（panic 超出恢复次数等）时，按策略重建 Group
+//
+// 调用 Stop、Shutdown 正常停止的 Group 不会重启，包括 Shutdown 超时和 GroupContext 的 ctx 结束
+type Supervisor struct {
+	g        *Group // 监管者自身的协程，串行处理 Group 退出
+	config   supervisorConfig
+	restarts []time.Time // 统计窗口内的重启时间
+	stopping bool        // 停止中，不再重启
+
+	mu       sync.Mutex
+	children []*supervisedChild
+}
+
+type supervisedChild struct {
+	spec ChildSpec
+	g    *Group
+	gen  int // 每次启动、停止递增，用于忽略过期的退出通知
+}
+
+// Group 退出通知
+type childExit struct {
+	index int
+	gen   int
+	err   error
+}
+
+// 受影响的 Group 已停止，重启 [from, to) 的 Group
+type childRestart struct {
+	from, to int
+}
+
+const (
+	childExitEvent    = "childExit"
+	childRestartEvent = "childRestart"
+)
+
+// 构建监管者，按顺序启动 specs 中的 Group
+//
+// 任一 Group 的 Init 返回错误时，停止已启动的 Group，返回该错误
+func NewSupervisor(specs []ChildSpec, options ...SupervisorOption) (*Supervisor, error) {
+	config := supervisorConfig{
+		MaxRestarts:     3,
+		Period:          5 * time.Second,
+		ShutdownTimeout: 5 * time.Second,
+	}
+	for _, option := range options {
+		option(&config)
+	}
+
+	var groupOptions []GroupOption
+	if config.Name != "" {
+		groupOptions = append(groupOptions, GroupName(config.Name))
+	}
+	if config.Logger != nil {
+		groupOptions = append(groupOptions, GroupLogger(config.Logger))
+	}
+	if config.Clock != nil {
+		groupOptions = append(groupOptions, GroupClock(config.Clock))
+	}
+
+	s := &Supervisor{
+		g:      NewGroup(groupOptions...),
+		config: config,
+	}
+	s.g.ListenEvent(childExitEvent, func(arg interface{}) {
+		s.onExit(arg.(childExit))
+	})
+	s.g.ListenEvent(childRestartEvent, func(arg interface{}) {
+		s.onRestart(arg.(childRestart))
+	})
+
+	for _, spec := range specs {
+		s.children = append(s.children, &supervisedChild{spec: spec})
+	}
+
+	var (
+		err     error
+		started []*Group
+	)
+	s.g.runInGroup(context.Background(), func() {
+		for i := range s.children {
+			if err = s.start(i); err != nil {
+				started = s.detach(0, i+1)
+				return
+			}
+		}
+	})
+	if err != nil {
+		s.shutdownGroups(started, context.Background())
+		s.g.Stop()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// 名为 name 的 Group，重启后返回新的 Group
+func (s *Supervisor) Child(name string) *Group {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.children {
+		if c.spec.Name == name {
+			return c.g
+		}
+	}
+	return nil
+}
+
+// 立即停止所有 Group 和监管者
+func (s *Supervisor) Stop() {
+	s.g.runInGroup(context.Background(), func() {
+		s.stopping = true
+		for i := len(s.children) - 1; i >= 0; i-- {
+			s.children[i].gen++
+			s.children[i].g.Stop()
+		}
+	})
+	s.g.Stop()
+}
+
+// 按启动的逆序优雅停止所有 Group，然后停止监管者
+// 	在调用方协程中等待 Group 停止，期间 Stop 仍可立即停止
+// 	不可在 ChildSpec.Init 中调用，此时返回 ErrInGroup
+func (s *Supervisor) Shutdown(ctx context.Context) error {
+	if s.g.hub.inHub() {
+		return ErrInGroup
+	}
+	var groups []*Group
+	err := s.g.runInGroup(ctx, func() {
+		s.stopping = true
+		groups = s.detach(0, len(s.children))
+	})
+	if err != nil {
+		s.g.Stop()
+		return err
+	}
+	s.shutdownGroups(groups, ctx)
+	return s.g.Shutdown(ctx)
+}
+
+// 监管者停止后关闭
+func (s *Supervisor) Done() <-chan struct{} {
+	return s.g.Done()
+}
+
+// 监管者的退出原因
+// 	ErrGroupStopped 正常停止
+// 	ErrRestartIntensity 重启次数超出限制
+func (s *Supervisor) Err() error {
+	return s.g.Err()
+}
+
+// 启动第 i 个 Group，在监管者协程中调用
+func (s *Supervisor) start(i int) error {
+	c := s.children[i]
+	options := c.spec.Options
+	if c.spec.Name != "" {
+		options = append(append([]GroupOption{}, options...), GroupName(c.spec.Name))
+	}
+
+	g := NewGroup(options...)
+	s.mu.Lock()
+	c.g = g
+	c.gen++
+	s.mu.Unlock()
+
+	if c.spec.Init != nil {
+		if err := c.spec.Init(g); err != nil {
+			g.Stop()
+			return fmt.Errorf("init %s: %w", g.Name(), err)
+		}
+	}
+
+	gen := c.gen
+	go func() {
+		select {
+		case <-g.Done():
+			s.g.Emit(childExitEvent, childExit{index: i, gen: gen, err: g.Err()})
+		case <-s.g.Done():
+		}
+	}()
+	return nil
+}
+
+// 不再监管 [from, to) 的 Group，忽略它们之后的退出通知，按逆序返回待停止的 Group
+// 在监管者协程中调用
+func (s *Supervisor) detach(from, to int) []*Group {
+	var groups []*Group
+	for i := to - 1; i >= from; i-- {
+		c := s.children[i]
+		c.gen++
+		if c.g != nil {
+			groups = append(groups, c.g)
+		}
+	}
+	return groups
+}
+
+// 依次优雅停止 groups，每个最多等待 ShutdownTimeout
+// 	可能较慢，不在监管者协程中调用，避免阻塞其他 Group 的退出通知和 Stop
+func (s *Supervisor) shutdownGroups(groups []*Group, ctx context.Context) {
+	for _, g := range groups {
+		stopCtx, cancel := context.WithTimeout(ctx, s.config.ShutdownTimeout)
+		if err := g.Shutdown(stopCtx); err != nil {
+			s.g.hub.log.warn("shutdown child", "child", g.Name(), "error", err)
+		}
+		cancel()
+	}
+}
+
+// 处理 Group 退出，在监管者协程中调用
+func (s *Supervisor) onExit(e childExit) {
+	c := s.children[e.index]
+	if s.stopping || c.gen != e.gen {
+		return // 已被监管者停止或重启
+	}
+	if normalExit(e.err) {
+		s.g.hub.log.debug("child stopped", "child", c.g.Name(), "error", e.err)
+		return
+	}
+
+	s.g.hub.log.warn("child exit", "child", c.g.Name(), "error", e.err)
+
+	now := s.g.clock.Now()
+	if !s.allowRestart(now) {
+		s.stopping = true
+		groups := s.detach(0, len(s.children))
+		err := fmt.Errorf("%w: %s: %v", ErrRestartIntensity, c.g.Name(), e.err)
+		go func() {
+			s.shutdownGroups(groups, context.Background())
+			s.g.stop(err)
+		}()
+		return
+	}
+
+	from, to := e.index, e.index+1
+	switch s.config.Strategy {
+	case OneForAll:
+		from, to = 0, len(s.children)
+	case RestForOne:
+		to = len(s.children)
+	}
+
+	// 在其他协程中停止受影响的 Group，异常退出的 Group 已经停止，全部停止后再重启
+	groups := append(s.detach(e.index+1, to), s.detach(from, e.index)...)
+	c.gen++
+	go func() {
+		s.shutdownGroups(groups, context.Background())
+		s.g.Emit(childRestartEvent, childRestart{from: from, to: to})
+	}()
+}
+
+// 受影响的 Group 已停止，等待 backoff 后重启，在监管者协程中调用
+func (s *Supervisor) onRestart(r childRestart) {
+	if s.stopping {
+		return
+	}
+
+	from, to := r.from, r.to
+	s.g.AfterFunc(s.backoff(), func() {
+		if s.stopping {
+			return
+		}
+		for i := from; i < to; i++ {
+			if err := s.start(i); err != nil {
+				s.onExit(childExit{index: i, gen: s.children[i].gen, err: err})
+				return
+			}
+		}
+	})
+}
+
+// 是否为正常停止的退出原因
+func normalExit(err error) bool {
+	var se *ShutdownError
+	return errors.Is(err, ErrGroupStopped) || errors.As(err, &se) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// 记录本次重启，返回是否在重启强度内
+func (s *Supervisor) allowRestart(now time.Time) bool {
+	restarts := s.restarts[:0]
+	for _, t := range s.restarts {
+		if now.Sub(t) < s.config.Period {
+			restarts = append(restarts, t)
+		}
+	}
+	s.restarts = append(restarts, now)
+	return len(s.restarts) <= s.config.MaxRestarts
+}
+
+// 重启前的等待时间
+func (s *Supervisor) backoff() time.Duration {
+	d, max := s.config.BackoffMin, s.config.BackoffMax
+	for n := 1; n < len(s.restarts) && d > 0 && d <= math.MaxInt64/2; n++ {
+		if max > 0 && d >= max {
+			break
+		}
+		d *= 2
+	}
+	if max > 0 && d > max {
+		d = max
+	}
+	return d
+}
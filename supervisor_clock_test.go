@@ -0,0 +1,74 @@
+package hub_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goSeeFuture/hub"
+	"github.com/goSeeFuture/hub/hubtest"
+)
+
+// 等待名为 name 的 Group 有 n 个未执行的定时器
+func waitTimers(t *testing.T, name string, n int) {
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, info := range hub.Groups() {
+			if info.Name == name && info.Timers == n {
+				return
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("%s timers != %d", name, n)
+}
+
+func Test_SupervisorBackoff(t *testing.T) {
+	cases := []struct {
+		name string
+		max  time.Duration
+		want []time.Duration
+	}{
+		{"不限", 0, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}},
+		{"最长3秒", 3 * time.Second, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clock := hubtest.NewFakeClock(time.Date(2021, 3, 5, 10, 0, 0, 0, time.UTC))
+			s, err := hub.NewSupervisor([]hub.ChildSpec{{
+				Name:    "backoff.child",
+				Options: []hub.GroupOption{hub.GroupRecovery(0)},
+				Init: func(g *hub.Group) error {
+					g.ListenEvent("crash", func(arg interface{}) { panic(arg) })
+					return nil
+				},
+			}}, hub.SupervisorName("backoff"), hub.SupervisorClock(clock),
+				hub.SupervisorBackoff(time.Second, c.max), hub.SupervisorIntensity(10, time.Hour))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Stop()
+
+			for _, d := range c.want {
+				old := s.Child("backoff.child")
+				old.Emit("crash", "crash")
+				waitTimers(t, "backoff", 1)
+
+				// 监管者协程接收下一个 tick 时，已处理完上一个 tick
+				clock.Advance(d - 20*time.Millisecond)
+				clock.Advance(10 * time.Millisecond)
+				if s.Child("backoff.child") != old {
+					t.Fatalf("restarted before %v", d)
+				}
+
+				clock.Advance(10 * time.Millisecond)
+				deadline := time.Now().Add(3 * time.Second)
+				for g := s.Child("backoff.child"); g == old || !g.IsWorking(); g = s.Child("backoff.child") {
+					if time.Now().After(deadline) {
+						t.Fatalf("not restarted after %v", d)
+					}
+					time.Sleep(time.Millisecond)
+				}
+			}
+		})
+	}
+}
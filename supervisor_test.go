@@ -0,0 +1,263 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func crashSpec(name string, inits *int32) ChildSpec {
+	return ChildSpec{
+		Name:    name,
+		Options: []GroupOption{GroupRecovery(0)},
+		Init: func(g *Group) error {
+			atomic.AddInt32(inits, 1)
+			g.ListenEvent("crash", func(arg interface{}) {
+				panic(arg)
+			})
+			return nil
+		},
+	}
+}
+
+// 等待 name 被替换为新的 Group
+func waitRestart(t *testing.T, s *Supervisor, name string, old *Group) *Group {
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if g := s.Child(name); g != old && g.IsWorking() {
+			return g
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("%s not restarted", name)
+	return nil
+}
+
+// 把 Debug 及以上级别的日志消息发送到 msgs，用于等待监管者处理完退出通知
+type tLogHandler struct {
+	msgs chan string
+}
+
+func (tLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelDebug
+}
+
+func (h tLogHandler) Handle(_ context.Context, r slog.Record) error {
+	select {
+	case h.msgs <- r.Message:
+	default:
+	}
+	return nil
+}
+
+func (h tLogHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h tLogHandler) WithGroup(string) slog.Handler      { return h }
+
+// 等待消息为 msg 的日志
+func waitLog(t *testing.T, msgs <-chan string, msg string) {
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case m := <-msgs:
+			if m == msg {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("no log %q", msg)
+		}
+	}
+}
+
+func Test_Supervisor(t *testing.T) {
+	t.Run("OneForOne", func(t *testing.T) {
+		var a, b int32
+		s, err := NewSupervisor([]ChildSpec{crashSpec("a", &a), crashSpec("b", &b)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Stop()
+
+		old, other := s.Child("a"), s.Child("b")
+		old.Emit("crash", 1)
+		waitRestart(t, s, "a", old)
+		if atomic.LoadInt32(&a) != 2 || atomic.LoadInt32(&b) != 1 || s.Child("b") != other {
+			t.Fatalf("inits a=%d b=%d", a, b)
+		}
+	})
+
+	t.Run("RestForOne", func(t *testing.T) {
+		var a, b, c int32
+		s, err := NewSupervisor([]ChildSpec{crashSpec("a", &a), crashSpec("b", &b), crashSpec("c", &c)},
+			SupervisorStrategy(RestForOne))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Stop()
+
+		first, last := s.Child("a"), s.Child("c")
+		s.Child("b").Emit("crash", 1)
+		waitRestart(t, s, "c", last)
+		if s.Child("a") != first || atomic.LoadInt32(&b) != 2 || atomic.LoadInt32(&c) != 2 {
+			t.Fatalf("inits a=%d b=%d c=%d", a, b, c)
+		}
+	})
+
+	t.Run("OneForAll", func(t *testing.T) {
+		var a, b int32
+		s, err := NewSupervisor([]ChildSpec{crashSpec("a", &a), crashSpec("b", &b)},
+			SupervisorStrategy(OneForAll))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Stop()
+
+		first := s.Child("a")
+		s.Child("b").Emit("crash", 1)
+		waitRestart(t, s, "a", first)
+		if atomic.LoadInt32(&a) != 2 {
+			t.Fatalf("inits a=%d", a)
+		}
+	})
+
+	t.Run("重启时停止卡住的Group不阻塞监管者", func(t *testing.T) {
+		var a int32
+		logs := make(chan string, 64)
+		release := make(chan struct{})
+		defer close(release)
+		stuck := ChildSpec{Name: "stuck", Init: func(g *Group) error {
+			g.OnStop(func() { <-release })
+			return nil
+		}}
+		s, err := NewSupervisor([]ChildSpec{crashSpec("a", &a), stuck},
+			SupervisorStrategy(OneForAll), SupervisorShutdownTimeout(time.Minute), SupervisorLogger(tLogHandler{logs}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s.Child("a").Emit("crash", 1)
+		waitLog(t, logs, "child exit")
+
+		stopped := make(chan struct{})
+		go func() {
+			s.Stop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(3 * time.Second):
+			t.Fatal("Stop blocked by stuck child")
+		}
+	})
+
+	t.Run("正常停止不重启", func(t *testing.T) {
+		var a int32
+		logs := make(chan string, 64)
+		s, err := NewSupervisor([]ChildSpec{crashSpec("a", &a)}, SupervisorLogger(tLogHandler{logs}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Stop()
+
+		g := s.Child("a")
+		g.Stop()
+		waitLog(t, logs, "child stopped")
+		if s.Child("a") != g || atomic.LoadInt32(&a) != 1 {
+			t.Fatal("restarted after stop")
+		}
+	})
+
+	t.Run("Shutdown超时不重启", func(t *testing.T) {
+		var a int32
+		logs := make(chan string, 64)
+		s, err := NewSupervisor([]ChildSpec{crashSpec("a", &a)}, SupervisorLogger(tLogHandler{logs}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Stop()
+
+		g := s.Child("a")
+		release := make(chan struct{})
+		g.OnStop(func() { <-release })
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		var se *ShutdownError
+		if err := g.Shutdown(ctx); !errors.As(err, &se) {
+			t.Fatalf("unexpected %v", err)
+		}
+		close(release)
+		waitLog(t, logs, "child stopped")
+		if s.Child("a") != g || atomic.LoadInt32(&a) != 1 {
+			t.Fatal("restarted after shutdown timeout")
+		}
+	})
+
+	t.Run("ctx结束不重启", func(t *testing.T) {
+		var a int32
+		ctx, cancel := context.WithCancel(context.Background())
+		spec := crashSpec("a", &a)
+		spec.Options = append(spec.Options, GroupContext(ctx))
+		logs := make(chan string, 64)
+		s, err := NewSupervisor([]ChildSpec{spec}, SupervisorLogger(tLogHandler{logs}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Stop()
+
+		g := s.Child("a")
+		cancel()
+		waitLog(t, logs, "child stopped")
+		if s.Child("a") != g || atomic.LoadInt32(&a) != 1 {
+			t.Fatal("restarted after ctx done")
+		}
+	})
+
+	t.Run("重启强度", func(t *testing.T) {
+		var a int32
+		s, err := NewSupervisor([]ChildSpec{crashSpec("a", &a)},
+			SupervisorIntensity(1, time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		old := s.Child("a")
+		old.Emit("crash", 1)
+		waitRestart(t, s, "a", old).Emit("crash", 2)
+
+		select {
+		case <-s.Done():
+		case <-time.After(3 * time.Second):
+			t.Fatal("supervisor not stopped")
+		}
+		if !errors.Is(s.Err(), ErrRestartIntensity) {
+			t.Fatalf("unexpected %v", s.Err())
+		}
+	})
+
+	t.Run("Init失败", func(t *testing.T) {
+		fail := errors.New("fail")
+		_, err := NewSupervisor([]ChildSpec{{Name: "a", Init: func(g *Group) error { return fail }}})
+		if !errors.Is(err, fail) {
+			t.Fatalf("unexpected %v", err)
+		}
+	})
+
+	t.Run("Shutdown", func(t *testing.T) {
+		var a int32
+		s, err := NewSupervisor([]ChildSpec{crashSpec("a", &a)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		g := s.Child("a")
+		if err := s.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		<-g.Done()
+		if s.Err() != ErrGroupStopped {
+			t.Fatalf("unexpected %v", s.Err())
+		}
+	})
+}
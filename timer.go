@@ -0,0 +1,32 @@
+package hub
+
+import "time"
+
+// 定时器，由 Group.AfterFunc、Group.Tick 创建
+//
+// 回调在 group 协程中执行，Stop、Reset 可在任意协程调用
+type Timer struct {
+	w      *timerWheel
+	expire uint64        // 到期的 tick 序号
+	period time.Duration // Tick 的间隔，AfterFunc 为0
+	fn     func() bool   // 返回 true 时间隔 period 再次执行
+	active bool          // 等待执行中
+	gen    uint64        // 每次加入时间轮递增，用于识别过期的执行请求
+
+	prev, next *Timer
+	list       *timerList // 所在槽，nil 表示未挂在时间轮上
+}
+
+// 停止定时器，返回 false 表示定时器已经执行或已经停止
+//
+// 在回调中停止 Tick 定时器，本次执行后不再继续
+func (t *Timer) Stop() bool {
+	return t.w.remove(t)
+}
+
+// 重置定时器在 d 时间后执行，Tick 定时器的间隔同时改为 d
+//
+// 返回 true 表示重置前定时器在等待执行
+func (t *Timer) Reset(d time.Duration) bool {
+	return t.w.reset(t, d)
+}
@@ -0,0 +1,58 @@
+package hub_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goSeeFuture/hub"
+	"github.com/goSeeFuture/hub/hubtest"
+)
+
+func Test_Timer(t *testing.T) {
+	start := time.Date(2021, 3, 5, 10, 0, 0, 0, time.UTC)
+	clock := hubtest.NewFakeClock(start)
+	g := hub.NewGroup(hub.GroupClock(clock))
+	defer g.Stop()
+
+	ticks := make(chan time.Time, 10)
+	g.Tick(20*time.Millisecond, func() bool {
+		ticks <- clock.Now()
+		return len(ticks) < 3
+	})
+
+	after := make(chan time.Time, 1)
+	g.AfterFunc(50*time.Millisecond, func() {
+		after <- clock.Now()
+	})
+
+	stopped := g.AfterFunc(20*time.Millisecond, func() {
+		t.Error("stopped AfterFunc fired")
+	})
+	if !stopped.Stop() {
+		t.Fatal("stop pending AfterFunc failed")
+	}
+	hubtest.Flush(g)
+
+	// 每次拨动一个刻度，Flush 后该刻度的回调已执行完
+	for i := 0; i < 10; i++ {
+		clock.Advance(10 * time.Millisecond)
+		hubtest.Flush(g)
+	}
+
+	if len(ticks) != 3 {
+		t.Fatalf("want 3 ticks, got %d", len(ticks))
+	}
+	for i := 1; i <= 3; i++ {
+		if d := (<-ticks).Sub(start); d != time.Duration(i)*20*time.Millisecond {
+			t.Fatalf("tick %d fired at %v", i, d)
+		}
+	}
+	select {
+	case tm := <-after:
+		if d := tm.Sub(start); d != 50*time.Millisecond {
+			t.Fatalf("AfterFunc fired at %v", d)
+		}
+	default:
+		t.Fatal("AfterFunc not fired")
+	}
+}
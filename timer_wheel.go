@@ -0,0 +1,319 @@
+package hub
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultTimerTick = 10 * time.Millisecond // 时间轮默认精度
+
+	wheelBits   = 6
+	wheelSize   = 1 << wheelBits // 每层槽数
+	wheelMask   = wheelSize - 1
+	wheelLevels = 6 // 层数，默认精度下可容纳约21年的延时
+)
+
+// 槽内定时器双向链表，head 为哨兵
+type timerList struct {
+	head Timer
+}
+
+func (l *timerList) init() {
+	l.head.prev = &l.head
+	l.head.next = &l.head
+}
+
+func (l *timerList) push(t *Timer) {
+	t.list = l
+	t.prev = l.head.prev
+	t.next = &l.head
+	l.head.prev.next = t
+	l.head.prev = t
+}
+
+func (l *timerList) remove(t *Timer) {
+	t.prev.next = t.next
+	t.next.prev = t.prev
+	t.prev, t.next, t.list = nil, nil, nil
+}
+
+// 取出槽内所有定时器
+func (l *timerList) take() []*Timer {
+	var ts []*Timer
+	for t := l.head.next; t != &l.head; {
+		next := t.next
+		t.prev, t.next, t.list = nil, nil, nil
+		ts = append(ts, t)
+		t = next
+	}
+	l.init()
+	return ts
+}
+
+// 已到期等待执行的定时器
+type expiredTimer struct {
+	t   *Timer
+	gen uint64
+}
+
+// 分层时间轮，插入、删除 O(1)
+//
+// 由 ticker 驱动，到期回调在 group 协程中执行；插入、删除可在任意协程调用
+type timerWheel struct {
+	mu      sync.Mutex
+	tick    time.Duration
+	now     func() time.Time
+	base    time.Time      // tick 序号的起点
+	current uint64         // 下一个待处理的 tick 序号
+	count   int            // 时间轮上的定时器数量
+	expired []expiredTimer // 已到期待执行的定时器
+	levels  [wheelLevels][wheelSize]timerList
+	ticker  Ticker
+	running bool // ticker 是否在运行，时间轮为空时停止 ticker
+	closed  bool
+}
+
+func newTimerWheel(tick time.Duration, clock Clock) *timerWheel {
+	if tick <= 0 {
+		tick = defaultTimerTick
+	}
+
+	w := &timerWheel{
+		tick: tick,
+		now:  clock.Now,
+		base: clock.Now(),
+	}
+	for l := range w.levels {
+		for s := range w.levels[l] {
+			w.levels[l][s].init()
+		}
+	}
+
+	w.ticker = clock.NewTicker(tick)
+	w.ticker.Stop()
+	return w
+}
+
+// ticker 通道，附加到 hub 上
+func (w *timerWheel) C() <-chan time.Time {
+	return w.ticker.C()
+}
+
+// 创建定时器，d 时间后到期
+func (w *timerWheel) newTimer(d, period time.Duration, fn func() bool) *Timer {
+	t := &Timer{w: w, period: period, fn: fn}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.add(t, w.now(), d)
+	return t
+}
+
+// 重置定时器
+func (w *timerWheel) reset(t *Timer, d time.Duration) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	active := t.active
+	if t.period > 0 {
+		t.period = d
+	}
+	w.add(t, w.now(), d)
+	return active
+}
+
+// 停止定时器
+func (w *timerWheel) remove(t *Timer) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	active := t.active
+	t.active = false
+	if t.list != nil {
+		t.list.remove(t)
+		w.count--
+	}
+	return active
+}
+
+// 加入定时器，调用方持有锁
+func (w *timerWheel) add(t *Timer, now time.Time, d time.Duration) {
+	if w.closed {
+		t.active = false
+		return
+	}
+
+	if t.list != nil {
+		t.list.remove(t)
+		w.count--
+	}
+
+	if w.count == 0 {
+		// 空闲期间没有待处理的 tick，直接对齐到当前时间
+		if n := w.tickOf(now); n > w.current {
+			w.current = n
+		}
+		if !w.running {
+			w.running = true
+			w.ticker.Reset(w.tick)
+		}
+	}
+
+	// 向上取整，保证不会提前触发
+	expire := uint64((now.Sub(w.base) + d + w.tick - 1) / w.tick)
+	if now.Before(w.base) || expire < w.current {
+		expire = w.current
+	}
+	t.expire = expire
+	t.active = true
+	t.gen++
+	w.place(t)
+	w.count++
+}
+
+// 按到期时间放入对应层的槽中
+func (w *timerWheel) place(t *Timer) {
+	delta := t.expire - w.current
+	level := 0
+	for level < wheelLevels-1 && delta >= 1<<(wheelBits*(level+1)) {
+		level++
+	}
+	if delta >= 1<<(wheelBits*wheelLevels) {
+		// 超出时间轮范围，放到最高层最远处，转动时再次降级
+		t.expire = w.current + 1<<(wheelBits*wheelLevels) - 1
+	}
+
+	slot := (t.expire >> (wheelBits * level)) & wheelMask
+	w.levels[level][slot].push(t)
+}
+
+func (w *timerWheel) tickOf(now time.Time) uint64 {
+	if now.Before(w.base) {
+		return 0
+	}
+	return uint64(now.Sub(w.base) / w.tick)
+}
+
+// 转动时间轮到 now，在 group 协程中执行到期的定时器
+func (w *timerWheel) advance(now time.Time) {
+	w.mu.Lock()
+	target := w.tickOf(now)
+	for w.current <= target {
+		if w.count == 0 {
+			w.current = target + 1
+			break
+		}
+
+		index := w.current & wheelMask
+		if index == 0 {
+			w.cascade(1)
+		}
+
+		expired := w.levels[0][index].take()
+		w.count -= len(expired)
+		for _, t := range expired {
+			w.expired = append(w.expired, expiredTimer{t: t, gen: t.gen})
+		}
+		w.current++
+	}
+	w.mu.Unlock()
+
+	for w.fire(now) {
+	}
+
+	w.mu.Lock()
+	if w.count == 0 && w.running {
+		w.running = false
+		w.ticker.Stop()
+	}
+	w.mu.Unlock()
+}
+
+// 将 level 层当前槽的定时器降级，槽序号为0时继续降级更高层
+func (w *timerWheel) cascade(level int) {
+	if level >= wheelLevels {
+		return
+	}
+
+	index := (w.current >> (wheelBits * level)) & wheelMask
+	if index == 0 {
+		w.cascade(level + 1)
+	}
+
+	for _, t := range w.levels[level][index].take() {
+		w.place(t)
+	}
+}
+
+// 执行一个到期的定时器，没有到期定时器时返回 false
+//
+// 逐个取出执行，回调 panic 恢复后，剩余的定时器在下次转动时继续执行
+func (w *timerWheel) fire(now time.Time) bool {
+	w.mu.Lock()
+	if len(w.expired) == 0 {
+		w.mu.Unlock()
+		return false
+	}
+	e := w.expired[0]
+	w.expired[0] = expiredTimer{}
+	w.expired = w.expired[1:]
+
+	t := e.t
+	if !t.active || t.gen != e.gen || t.list != nil {
+		// 到期后被停止或重置
+		w.mu.Unlock()
+		return true
+	}
+	if t.period <= 0 {
+		t.active = false
+		w.mu.Unlock()
+		t.fn()
+		return true
+	}
+	w.mu.Unlock()
+
+	// 回调 panic 时同样重新加入，group 恢复后继续周期执行
+	again := true
+	defer func() {
+		w.mu.Lock()
+		if !again {
+			t.active = false
+		} else if t.active && t.gen == e.gen {
+			// 回调中没有停止或重置
+			w.add(t, now, t.period)
+		}
+		w.mu.Unlock()
+	}()
+	again = t.fn()
+	return true
+}
+
+// 未执行的定时器数量，包括已到期待执行的，可在任意协程调用
+func (w *timerWheel) pending() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.count + len(w.expired)
+}
+
+// 停止 ticker，丢弃所有定时器
+func (w *timerWheel) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	w.running = false
+	w.ticker.Stop()
+	for l := range w.levels {
+		for s := range w.levels[l] {
+			for _, t := range w.levels[l][s].take() {
+				t.active = false
+			}
+		}
+	}
+	for _, e := range w.expired {
+		e.t.active = false
+	}
+	w.expired = nil
+	w.count = 0
+}
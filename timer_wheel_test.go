@@ -0,0 +1,199 @@
+package hub
+
+import (
+	"testing"
+	"time"
+)
+
+// 测试用的时间轮，时间由 now 指定
+type testWheel struct {
+	*timerWheel
+	now time.Time
+}
+
+func (tw *testWheel) Now() time.Time {
+	return tw.now
+}
+
+func (tw *testWheel) NewTicker(d time.Duration) Ticker {
+	return realClock{}.NewTicker(d)
+}
+
+func newTestWheel(tick time.Duration) *testWheel {
+	tw := &testWheel{now: time.Now()}
+	tw.timerWheel = newTimerWheel(tick, tw)
+	return tw
+}
+
+// 拨动时间到 now，并执行到期的定时器
+func (tw *testWheel) advanceTo(now time.Time) {
+	tw.now = now
+	tw.advance(now)
+}
+
+func Test_timerWheel(t *testing.T) {
+	tick := 10 * time.Millisecond
+
+	t.Run("按到期时间执行", func(t *testing.T) {
+		w := newTestWheel(tick)
+		defer w.stop()
+		base := w.now
+
+		fired := make(map[time.Duration]time.Time)
+		delays := []time.Duration{
+			0, 5 * time.Millisecond, 10 * time.Millisecond, 640 * time.Millisecond,
+			time.Second, time.Minute, time.Hour,
+		}
+		for _, d := range delays {
+			d := d
+			w.newTimer(d, 0, func() bool {
+				fired[d] = w.now
+				return false
+			})
+		}
+
+		for now := base; now.Before(base.Add(time.Hour + time.Second)); now = now.Add(3 * time.Millisecond) {
+			w.advanceTo(now)
+		}
+		for _, d := range delays {
+			at, ok := fired[d]
+			if !ok {
+				t.Fatalf("%v not fired", d)
+			}
+			if at.Sub(base) < d || at.Sub(base) > d+2*tick {
+				t.Fatalf("%v fired at %v", d, at.Sub(base))
+			}
+		}
+	})
+
+	t.Run("停止后不执行", func(t *testing.T) {
+		w := newTestWheel(tick)
+		defer w.stop()
+		base := w.now
+
+		tm := w.newTimer(time.Second, 0, func() bool {
+			t.Fatal("stopped timer fired")
+			return false
+		})
+		if !tm.Stop() {
+			t.Fatal("stop pending timer failed")
+		}
+		if tm.Stop() {
+			t.Fatal("stop twice")
+		}
+		w.advanceTo(base.Add(2 * time.Second))
+	})
+
+	t.Run("重置", func(t *testing.T) {
+		w := newTestWheel(tick)
+		defer w.stop()
+		base := w.now
+
+		var n int
+		tm := w.newTimer(time.Second, 0, func() bool {
+			n++
+			return false
+		})
+		w.advanceTo(base.Add(500 * time.Millisecond))
+		if !tm.Reset(time.Second) {
+			t.Fatal("reset pending timer should return true")
+		}
+		w.advanceTo(base.Add(1200 * time.Millisecond))
+		if n != 0 {
+			t.Fatal("reset timer fired at old deadline")
+		}
+		w.advanceTo(base.Add(1600 * time.Millisecond))
+		if n != 1 {
+			t.Fatalf("want 1 fire, got %d", n)
+		}
+		if tm.Stop() {
+			t.Fatal("stop fired timer should return false")
+		}
+		if tm.Reset(100 * time.Millisecond) {
+			t.Fatal("reset fired timer should return false")
+		}
+		w.advanceTo(base.Add(1800 * time.Millisecond))
+		if n != 2 {
+			t.Fatalf("want 2 fires, got %d", n)
+		}
+	})
+
+	t.Run("周期执行", func(t *testing.T) {
+		w := newTestWheel(tick)
+		defer w.stop()
+		base := w.now
+
+		var n int
+		w.newTimer(100*time.Millisecond, 100*time.Millisecond, func() bool {
+			n++
+			return n < 3
+		})
+
+		for i := 1; i <= 5; i++ {
+			w.advanceTo(base.Add(time.Duration(i) * 100 * time.Millisecond))
+		}
+		if n != 3 {
+			t.Fatalf("want 3 ticks, got %d", n)
+		}
+	})
+
+	t.Run("回调中停止周期定时器", func(t *testing.T) {
+		w := newTestWheel(tick)
+		defer w.stop()
+		base := w.now
+
+		var n int
+		var tm *Timer
+		tm = w.newTimer(100*time.Millisecond, 100*time.Millisecond, func() bool {
+			n++
+			if !tm.Stop() {
+				t.Error("stop running tick should return true")
+			}
+			return true
+		})
+
+		for i := 1; i <= 3; i++ {
+			w.advanceTo(base.Add(time.Duration(i) * 100 * time.Millisecond))
+		}
+		if n != 1 {
+			t.Fatalf("want 1 tick, got %d", n)
+		}
+	})
+
+	t.Run("周期回调panic后继续执行", func(t *testing.T) {
+		w := newTestWheel(tick)
+		defer w.stop()
+		base := w.now
+
+		var n int
+		tm := w.newTimer(100*time.Millisecond, 100*time.Millisecond, func() bool {
+			n++
+			if n == 1 {
+				panic("tick")
+			}
+			return true
+		})
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("no panic")
+				}
+			}()
+			w.advanceTo(base.Add(100 * time.Millisecond))
+		}()
+		if w.pending() != 1 {
+			t.Fatalf("pending %d", w.pending())
+		}
+
+		for i := 2; i <= 3; i++ {
+			w.advanceTo(base.Add(time.Duration(i) * 100 * time.Millisecond))
+		}
+		if n != 3 {
+			t.Fatalf("want 3 ticks, got %d", n)
+		}
+		if !tm.Stop() || w.pending() != 0 {
+			t.Fatal("stop active tick failed")
+		}
+	})
+}
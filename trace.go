@@ -0,0 +1,92 @@
+package hub
+
+import (
+	"context"
+	"time"
+)
+
+// 追踪接口，适配 OpenTelemetry 等，见 hubotel
+//
+// Emit、Call、SlowCall 的 ctx 随数据进入 group 协程，处理时以其中的 span 为父 span，
+// 记录收件箱等待、处理函数执行和 SlowCall 执行；处理链中各处理器的执行记录为根 span
+type Tracer interface {
+	// 以 ctx 中的 span 为父 span，开始名为 name 的 span，返回带有新 span 的 ctx
+	// 	attrs 为成对的键、值
+	Start(ctx context.Context, name string, start time.Time, attrs ...interface{}) (context.Context, Span)
+}
+
+// 进行中的 span
+type Span interface {
+	// 结束 span，err 非 nil 时标记为失败
+	End(end time.Time, err error)
+}
+
+// span 名称，处理函数执行的 span 同 PanicInfo.Handler，如 event:login、call:query
+const (
+	SpanQueuePrefix     = "queue:"     // 收件箱等待，如 queue:event:login
+	SpanProcessorPrefix = "processor:" // 处理链中处理器的执行，如 processor:decoder
+	SpanSlowCall        = "SlowCall"   // SlowCall 的 fn 执行
+	SpanSlowCallback    = "SlowCall callback"
+)
+
+// span 属性
+const (
+	AttrGroup = "hub.group"
+)
+
+// 开始在 group 协程中处理 name，ctx 为调用方的 ctx，只能在 group 协程中调用
+// 	启用追踪时，记录从 queued 开始的收件箱等待，并开始处理函数执行的 span，queued 为零值时不记录等待
+func (g *Group) begin(ctx context.Context, name string, queued time.Time) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	g.ctx = ctx
+
+	tracer := g.config.Tracer
+	if tracer == nil {
+		return
+	}
+
+	if !queued.IsZero() {
+		g.traceWait(ctx, name, queued)
+	}
+	g.ctx, g.span = tracer.Start(ctx, name, time.Now(), AttrGroup, g.Name())
+}
+
+// 处理完成，结束处理函数执行的 span，只能在 group 协程中调用
+func (g *Group) end(err error) {
+	if g.span != nil {
+		g.span.End(time.Now(), err)
+		g.span = nil
+	}
+	g.ctx = nil
+}
+
+// 记录收件箱等待，只能在 group 协程中调用
+func (g *Group) traceWait(ctx context.Context, name string, queued time.Time) {
+	if g.config.Tracer != nil {
+		_, wait := g.config.Tracer.Start(ctx, SpanQueuePrefix+name, queued, AttrGroup, g.Name())
+		wait.End(time.Now(), nil)
+	}
+}
+
+// 正在执行的事件、调用处理函数或 SlowCall 回调的 ctx，只能在 group 协程中调用
+// 	启用追踪时带有处理函数执行的 span，传给 EmitContext、CallContext、SlowCallContext 可延续调用链
+// 	不在处理函数中时返回 context.Background()
+func (g *Group) HandlerContext() context.Context {
+	if g.ctx == nil {
+		return context.Background()
+	}
+	return g.ctx
+}
+
+// 开始 SlowCall 的 fn 执行的 span，未启用追踪时返回 nil
+func (g *Group) slowCallTrace() func(ctx context.Context) (context.Context, Span) {
+	tracer := g.config.Tracer
+	if tracer == nil {
+		return nil
+	}
+	return func(ctx context.Context) (context.Context, Span) {
+		return tracer.Start(ctx, SpanSlowCall, time.Now(), AttrGroup, g.Name())
+	}
+}
@@ -0,0 +1,147 @@
+package hub
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// 类型化处理函数的参数类型登记
+type typedKey struct {
+	call bool
+	name string
+}
+
+// 绑定类型化调用处理函数，注册到 ListenCall 同一集合
+//
+// 参数类型不符时，handler 不会执行，调用方得到 ErrTypeMismatch
+func Handle[Req, Resp any](g *Group, name string, handler func(Req) (Resp, error)) {
+	g.ListenCall(name, func(arg interface{}) Return {
+		req, err := typedValue[Req](name, arg)
+		if err != nil {
+			return Return{Error: err}
+		}
+
+		resp, err := handler(req)
+		return Return{Value: resp, Error: err}
+	})
+	g.types.Store(typedKey{call: true, name: name}, typeOf[Req]())
+}
+
+// 类型化调用，同 Call，阻塞等待 Handle 注册的处理函数返回
+func Invoke[Req, Resp any](g *Group, name string, req Req) (Resp, error) {
+	return InvokeContext[Req, Resp](context.Background(), g, name, req)
+}
+
+// 类型化调用，同 CallContext
+func InvokeContext[Req, Resp any](ctx context.Context, g *Group, name string, req Req) (resp Resp, err error) {
+	if err = g.checkType(typedKey{call: true, name: name}, req); err != nil {
+		return
+	}
+
+	ret, registered := g.CallContext(ctx, name, req)
+	if !registered {
+		return resp, fmt.Errorf("%w: call %s", ErrNotRegistered, name)
+	}
+	if ret.Error != nil {
+		if v, ok := ret.Value.(Resp); ok {
+			resp = v
+		}
+		return resp, ret.Error
+	}
+
+	return typedValue[Resp](name, ret.Value)
+}
+
+// 绑定类型化事件处理函数，注册到 ListenEvent 同一集合
+//
+// 参数类型不符时，丢弃该事件并记录日志，不会在 group 协程中 panic
+func HandleEvent[T any](g *Group, name string, handler func(T)) {
+	g.ListenEvent(name, func(arg interface{}) {
+		v, err := typedValue[T](name, arg)
+		if err != nil {
+			g.hub.log.warn("drop event", "event", name, "error", err)
+			return
+		}
+
+		handler(v)
+	})
+	g.types.Store(typedKey{name: name}, typeOf[T]())
+}
+
+// 发送类型化事件，同 Emit，发送前检查参数类型
+func EmitEvent[T any](g *Group, name string, arg T) error {
+	return EmitEventContext(context.Background(), g, name, arg)
+}
+
+// 发送类型化事件，同 EmitContext
+func EmitEventContext[T any](ctx context.Context, g *Group, name string, arg T) error {
+	if err := g.checkType(typedKey{name: name}, arg); err != nil {
+		return err
+	}
+
+	if !g.EmitContext(ctx, name, arg) {
+		return fmt.Errorf("%w: event %s", ErrNotRegistered, name)
+	}
+	return nil
+}
+
+// 增加类型化监听通道，同步等待，确保添加成功
+//
+// ch 的数据在 group 协程中直接交给 fn，不经过处理链；
+// ch 关闭后自动移除，不送出 ProducerClosed，也可以使用 Detach 移除
+func AttachTyped[T any](g *Group, ch <-chan T, fn func(T)) {
+	handler := func(data interface{}) {
+		v, _ := data.(T) // T 为接口类型时 data 可能为 nil
+		fn(v)
+	}
+	g.attachWait(func(cb func()) bool {
+		return g.hub.send(producerOp{producer: ch, info: producerInfo{handler: handler}, op: addProducer, cb: cb})
+	})
+}
+
+// 在调用方协程检查参数类型，未使用类型化注册的处理函数不检查
+func (g *Group) checkType(key typedKey, arg interface{}) error {
+	t, exist := g.types.Load(key)
+	if !exist {
+		return nil
+	}
+
+	want := t.(reflect.Type)
+	if arg == nil {
+		if nilable(want) {
+			return nil
+		}
+	} else if reflect.TypeOf(arg).AssignableTo(want) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s want %v, got %T", ErrTypeMismatch, key.name, want, arg)
+}
+
+// 断言 v 的类型为 T，v 为 nil 时返回 T 的零值（T 可为 nil 时）
+func typedValue[T any](name string, v interface{}) (T, error) {
+	if t, ok := v.(T); ok {
+		return t, nil
+	}
+
+	var zero T
+	if v == nil && nilable(typeOf[T]()) {
+		return zero, nil
+	}
+
+	return zero, fmt.Errorf("%w: %s want %v, got %T", ErrTypeMismatch, name, typeOf[T](), v)
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// 类型 t 的零值是否为 nil
+func nilable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package hub
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func Test_Typed(t *testing.T) {
+	g := NewGroup()
+	defer g.Stop()
+
+	Handle(g, "itoa", func(n int) (string, error) {
+		return strconv.Itoa(n), nil
+	})
+
+	t.Run("类型化调用", func(t *testing.T) {
+		s, err := Invoke[int, string](g, "itoa", 42)
+		if err != nil || s != "42" {
+			t.Fatalf("unexpected %q %v", s, err)
+		}
+	})
+
+	t.Run("参数类型不符", func(t *testing.T) {
+		_, err := Invoke[string, string](g, "itoa", "42")
+		if !errors.Is(err, ErrTypeMismatch) {
+			t.Fatalf("want ErrTypeMismatch, got %v", err)
+		}
+
+		// 未类型化的调用方，在 group 协程中检查
+		ret, _ := g.Call("itoa", "42")
+		if !errors.Is(ret.Error, ErrTypeMismatch) {
+			t.Fatalf("want ErrTypeMismatch, got %v", ret.Error)
+		}
+	})
+
+	t.Run("返回值类型不符", func(t *testing.T) {
+		_, err := Invoke[int, int](g, "itoa", 42)
+		if !errors.Is(err, ErrTypeMismatch) {
+			t.Fatalf("want ErrTypeMismatch, got %v", err)
+		}
+	})
+
+	t.Run("未注册", func(t *testing.T) {
+		_, err := Invoke[int, string](g, "none", 1)
+		if !errors.Is(err, ErrNotRegistered) {
+			t.Fatalf("want ErrNotRegistered, got %v", err)
+		}
+	})
+
+	t.Run("类型化事件", func(t *testing.T) {
+		got := make(chan []byte, 1)
+		HandleEvent(g, "bytes", func(b []byte) {
+			got <- b
+		})
+
+		if err := EmitEvent(g, "bytes", 1); !errors.Is(err, ErrTypeMismatch) {
+			t.Fatalf("want ErrTypeMismatch, got %v", err)
+		}
+		if err := EmitEvent(g, "bytes", []byte("ok")); err != nil {
+			t.Fatal(err)
+		}
+		if b := <-got; string(b) != "ok" {
+			t.Fatalf("unexpected %q", b)
+		}
+	})
+}
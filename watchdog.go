@@ -0,0 +1,153 @@
+package hub
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 处理超时报告
+type WatchdogReport struct {
+	Group    string
+	Handler  string        // 正在执行的处理器、事件或调用名称，如 event:login
+	DataType string        // 正在处理的数据类型
+	Elapsed  time.Duration // 当前数据已处理的时间
+	Stack    []byte        // 报告时 group 协程的调用栈
+	Repeat   int           // 同一数据的第几次报告，从1开始
+}
+
+// 看门狗，在独立协程中检查 hub 协程处理当前数据的时间
+type watchdog struct {
+	group     string
+	threshold time.Duration
+	repeat    bool // 超时后每隔 threshold 重复报告，直到处理完成
+	report    func(WatchdogReport)
+
+	mu      sync.Mutex
+	busy    bool
+	seq     uint64 // 每开始处理一条数据加1
+	running string
+	data    interface{}
+	start   time.Time
+	gid     int64 // hub 协程的 goroutine id
+}
+
+// 记录正在执行的处理，空闲时开始计时
+func (w *watchdog) track(running string, data interface{}) {
+	w.mu.Lock()
+	if !w.busy {
+		w.busy = true
+		w.seq++
+		w.start = time.Now()
+	}
+	w.running, w.data = running, data
+	w.mu.Unlock()
+}
+
+// hub 协程处理完当前数据，开始等待
+func (w *watchdog) idle() {
+	w.mu.Lock()
+	w.busy = false
+	w.data = nil
+	w.mu.Unlock()
+}
+
+// 记录 hub 协程的 goroutine id，panic 恢复后 hub 协程会变化
+func (w *watchdog) attach(id int64) {
+	w.mu.Lock()
+	w.gid = id
+	w.mu.Unlock()
+}
+
+// 定期检查，done 关闭后返回
+func (w *watchdog) run(done <-chan struct{}) {
+	interval := w.threshold / 4
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var (
+		reported uint64 // 已报告的数据序号
+		repeat   int
+		last     time.Time
+	)
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		w.mu.Lock()
+		busy, seq, running, data, start, gid := w.busy, w.seq, w.running, w.data, w.start, w.gid
+		w.mu.Unlock()
+
+		now := time.Now()
+		if !busy || now.Sub(start) < w.threshold {
+			continue
+		}
+		if seq == reported {
+			if !w.repeat || now.Sub(last) < w.threshold {
+				continue
+			}
+		} else {
+			repeat = 0
+		}
+		reported, last = seq, now
+		repeat++
+
+		w.report(WatchdogReport{
+			Group:    w.group,
+			Handler:  running,
+			DataType: fmt.Sprintf("%T", data),
+			Elapsed:  now.Sub(start),
+			Stack:    goroutineStack(gid),
+			Repeat:   repeat,
+		})
+	}
+}
+
+// 当前协程的 goroutine id
+func goroutineID() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	// goroutine 18 [running]:
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseInt(string(b), 10, 64)
+	return id
+}
+
+// 当前协程是否为 hub 协程
+func (h *Hub) inHub() bool {
+	return goroutineID() == atomic.LoadInt64(&h.gid)
+}
+
+// 从所有协程的调用栈中取出协程 id 的调用栈，未找到时返回 nil
+func goroutineStack(id int64) []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	header := []byte("goroutine " + strconv.FormatInt(id, 10) + " [")
+	for _, stack := range bytes.Split(buf, []byte("\n\n")) {
+		if bytes.HasPrefix(stack, header) {
+			return stack
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package hub
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+type tSlowArg struct{}
+
+func Test_Watchdog(t *testing.T) {
+	for _, e := range engines {
+		t.Run(e.name, func(t *testing.T) {
+			reports := make(chan WatchdogReport, 10)
+			g := NewGroup(GroupName("watch"), GroupEngine(e.engine),
+				GroupWatchdog(20*time.Millisecond, true),
+				GroupWatchdogHandler(func(r WatchdogReport) { reports <- r }))
+			release := make(chan struct{})
+			g.ListenEvent("slow", func(interface{}) { <-release })
+			g.ListenEvent("fast", func(interface{}) {})
+
+			g.Emit("fast", nil)
+			g.Emit("slow", tSlowArg{})
+			r := <-reports
+			if r.Group != "watch" || r.Handler != "event:slow" || r.DataType != "hub.tSlowArg" ||
+				r.Elapsed < 20*time.Millisecond || r.Repeat != 1 {
+				t.Fatalf("report %+v", r)
+			}
+			if !bytes.Contains(r.Stack, []byte("Test_Watchdog")) {
+				t.Fatalf("stack\n%s", r.Stack)
+			}
+
+			// 处理完成前重复报告
+			if r = <-reports; r.Handler != "event:slow" || r.Repeat != 2 {
+				t.Fatalf("report %+v", r)
+			}
+			close(release)
+
+			if err := g.Shutdown(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case r := <-reports:
+				if r.Handler != "event:slow" {
+					t.Fatalf("report %+v", r)
+				}
+			default:
+			}
+		})
+	}
+}